@@ -1,21 +1,29 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/McCzarny/ghsummary"
 	"github.com/McCzarny/ghsummary/utils"
 )
 
+// baseOptions are the options every request starts from. Tests set Replay
+// to serve recorded GitHub responses.
+var baseOptions ghsummary.Options
+
 func Handler(w http.ResponseWriter, r *http.Request) {
 	// Set the content type to SVG
 	w.Header().Set("Content-Type", "image/svg+xml")
 
-	// Extract username from query parameters
+	// Extract username or repository from query parameters
 	username := r.URL.Query().Get("username")
+	repo := r.URL.Query().Get("repo")
+	org := r.URL.Query().Get("org")
 	max_events_str := r.URL.Query().Get("max-events")
 	if max_events_str == "" {
 		max_events_str = "100"
@@ -27,18 +35,60 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if username == "" {
+	if org != "" {
+		if !utils.SanitizeUsername(org) {
+			http.Error(w, "Invalid 'org' query parameter", http.StatusBadRequest)
+			return
+		}
+	} else if repo != "" {
+		if !utils.SanitizeRepo(repo) {
+			http.Error(w, "Invalid 'repo' query parameter", http.StatusBadRequest)
+			return
+		}
+	} else if username == "" {
 		http.Error(w, "Missing 'username' query parameter", http.StatusBadRequest)
 		return
+	} else if !utils.SanitizeUsername(username) {
+		http.Error(w, "Invalid username", http.StatusBadRequest)
+		return
 	}
 
-	if !utils.SanitizeUsername(username) {
-		http.Error(w, "Invalid username", http.StatusBadRequest)
+	window, err := ghsummary.ParseTimeWindow(r.URL.Query().Get("since"), r.URL.Query().Get("until"), time.Now())
+	if err != nil {
+		log.Printf("Error parsing time window: %v", err)
+		http.Error(w, "Invalid 'since' or 'until' query parameter", http.StatusBadRequest)
 		return
 	}
 
+	summarizer, err := ghsummary.NewSummarizer(r.URL.Query().Get("provider"))
+	if err != nil {
+		log.Printf("Error creating summarizer: %v", err)
+		if errors.Is(err, ghsummary.ErrUnknownProvider) {
+			http.Error(w, "Invalid 'provider' query parameter", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to create summarizer", summaryErrorStatus(err))
+		return
+	}
+	if !baseOptions.DisableFallback {
+		if summarizer, err = ghsummary.WithTemplateFallback(summarizer, baseOptions.TemplateDir); err != nil {
+			log.Printf("Error loading templates: %v", err)
+			http.Error(w, "Failed to create summarizer", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// Fetch GitHub activity
-	activity, err := ghsummary.GetUserActivity(username, max_events, "fast")
+	opts := baseOptions
+	opts.Summarizer, opts.Since, opts.Until = summarizer, window.Since, window.Until
+	var activity string
+	if org != "" {
+		activity, err = ghsummary.GetOrganizationActivity(r.Context(), org, max_events, "fast", opts)
+	} else if repo != "" {
+		activity, err = ghsummary.GetRepositoryActivity(r.Context(), repo, max_events, "fast", opts)
+	} else {
+		activity, err = ghsummary.GetUserActivity(r.Context(), username, max_events, "fast", opts)
+	}
 	if err != nil {
 		log.Printf("Error fetching GitHub activity: %v", err)
 		http.Error(w, "Failed to fetch GitHub activity", http.StatusInternalServerError)
@@ -46,15 +96,22 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate summary using LLM
-	summary, err := ghsummary.GenerateSummary(activity)
+	var summary string
+	if org != "" {
+		summary, err = ghsummary.GenerateOrganizationSummary(r.Context(), summarizer, activity, org)
+	} else if repo != "" {
+		summary, err = ghsummary.GenerateRepositorySummary(r.Context(), summarizer, activity, repo)
+	} else {
+		summary, err = ghsummary.GenerateSummary(r.Context(), summarizer, activity)
+	}
 	if err != nil {
 		log.Printf("Error generating summary: %v", err)
-		http.Error(w, "Failed to generate summary", http.StatusInternalServerError)
+		http.Error(w, "Failed to generate summary", summaryErrorStatus(err))
 		return
 	}
 
 	// Generate SVG content
-	svgContent, err := ghsummary.GenerateSVG(summary, "")
+	svgContent, err := ghsummary.GenerateSVG(r.Context(), summary, "", window)
 	if err != nil {
 		log.Printf("Error generating SVG: %v", err)
 		http.Error(w, "Failed to generate SVG", http.StatusInternalServerError)
@@ -64,3 +121,16 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	// Write SVG content to response
 	fmt.Fprint(w, svgContent)
 }
+
+// summaryErrorStatus maps summarizer errors to the HTTP status reported to the client.
+func summaryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ghsummary.ErrQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ghsummary.ErrProviderUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ghsummary.ErrEmptyCompletion), errors.Is(err, ghsummary.ErrSafetyBlocked):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
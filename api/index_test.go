@@ -3,10 +3,17 @@ package handler
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/McCzarny/ghsummary"
 )
 
 func TestHandler(t *testing.T) {
+	// Serve the GitHub responses recorded for the user octo, with no network.
+	baseOptions = ghsummary.Options{Replay: &ghsummary.HTTPFixtures{Dir: "../testdata/replay/octo"}}
+	t.Cleanup(func() { baseOptions = ghsummary.Options{} })
+
 	tests := []struct {
 		name           string
 		queryParam     string
@@ -19,14 +26,19 @@ func TestHandler(t *testing.T) {
 		},
 		{
 			name:           "Valid username",
-			queryParam:     "McCzarny",
+			queryParam:     "octo",
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "Username without recorded activity",
+			queryParam:     "ghost",
+			expectedStatus: http.StatusInternalServerError,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest("GET", "/?username="+tt.queryParam, nil)
+			req := httptest.NewRequest("GET", "/?provider=fake&username="+tt.queryParam, nil)
 			w := httptest.NewRecorder()
 
 			Handler(w, req)
@@ -42,11 +54,11 @@ func TestHandler(t *testing.T) {
 					t.Errorf("expected content type 'image/svg+xml', got '%s'", contentType)
 				}
 			}
-			// Check if the response body is not empty
+			// Check if the response body contains the summary
 			if resp.StatusCode == http.StatusOK {
 				body := w.Body.String()
-				if body == "" {
-					t.Error("expected non-empty response body")
+				if !strings.Contains(body, "Fake summary of octo") {
+					t.Errorf("expected the summary in the response body, got %q", body)
 				}
 			}
 		})
@@ -1,48 +1,278 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/McCzarny/ghsummary"
 	"github.com/McCzarny/ghsummary/utils"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		runCacheCommand(os.Args[2:])
+		return
+	}
+
    flagSet := flag.NewFlagSet("args", flag.ExitOnError)
    username := flagSet.String("username", "", "GitHub username")
    outputFile := flagSet.String("output", "summary.svg", "Output SVG file")
    maxEvents := flagSet.Int("max-events", 100, "Maximum number of events to fetch")
    mode := flagSet.String("mode", "fast", "Mode of operation (fast, strict)")
    pronouns := flagSet.String("pronouns", "he/him", "Pronouns to use for the user (e.g. he/him, she/her, they/them)")
+   provider := flagSet.String("provider", "", fmt.Sprintf("Summarizer backend (%s), default $GHSUMMARY_PROVIDER or %s", strings.Join(ghsummary.Providers(), ", "), ghsummary.DefaultProvider))
+   templates := flagSet.String("templates", "", "Directory of templates (summary.tmpl, team.tmpl, repository.tmpl, organization.tmpl, commit.tmpl) overriding those of the template provider and fallback (default $GHSUMMARY_TEMPLATES)")
+   noFallback := flagSet.Bool("no-fallback", false, "Fail when the LLM fails instead of summarizing with templates")
+   authorEmails := flagSet.String("author-emails", "", "Comma-separated extra commit emails of the user, as email or email=login")
+   githubAPIURL := flagSet.String("github-api-url", "", "GitHub REST API root, e.g. https://ghe.example.com/api/v3 (default $GITHUB_API_URL or https://api.github.com)")
+   rateLimitWait := flagSet.Duration("rate-limit-wait", time.Minute, "Longest time to wait for the GitHub rate limit to reset before skipping requests")
+   cacheDir := flagSet.String("cache-dir", "", "Directory for caching GitHub responses and commit summaries between runs. Empty disables caching")
+   concurrency := flagSet.Int("concurrency", ghsummary.DefaultConcurrency, "Maximum number of GitHub and LLM requests made in parallel")
+   since := flagSet.String("since", "", "Only summarize activity since this date (2006-01-02, RFC 3339) or duration ago (e.g. 7d, 2w, 36h)")
+   until := flagSet.String("until", "", "Only summarize activity until this date (inclusive) or duration ago")
+   includeRepos := flagSet.String("include-repos", "", "Comma-separated owner/name globs; only matching repositories are summarized")
+   excludeRepos := flagSet.String("exclude-repos", "", "Comma-separated owner/name globs of repositories to leave out")
+   includeOrgs := flagSet.String("include-orgs", "", "Comma-separated owner globs; only repositories of matching users or orgs are summarized")
+   excludeOrgs := flagSet.String("exclude-orgs", "", "Comma-separated owner globs of users or orgs to leave out")
+   skipForks := flagSet.Bool("skip-forks", false, "Leave out forked repositories")
+   skipArchived := flagSet.Bool("skip-archived", false, "Leave out archived repositories")
+   skipTopics := flagSet.String("skip-topics", "", "Comma-separated repository topics to leave out")
+   private := flagSet.String("private", string(ghsummary.PrivateReposExclude), "Private repositories: exclude, anonymize (shown as \"a private repository\") or include")
+   users := flagSet.String("users", "", "Comma-separated usernames to summarize together as a team")
+   team := flagSet.String("team", "", "Organization (org) or team (org/team) whose members are summarized together")
+   repo := flagSet.String("repo", "", "Summarize the recent activity of this repository (owner/name) instead of a user")
+   org := flagSet.String("org", "", "Summarize the recent activity of this organization instead of a user")
+   maxRepos := flagSet.Int("max-repos", ghsummary.DefaultMaxRepositories, "Number of most active repositories covered by an organization summary")
+   source := flagSet.String("source", "rest", "Where user activity comes from: rest (recent events) or graphql (contributions calendar, needs GITHUB_TOKEN)")
+   gitRepos := flagSet.String("git-repos", "", "Comma-separated paths of local git repositories whose commits by the user are summarized together with the GitHub activity")
+   gitlabUser := flagSet.String("gitlab-user", "", "GitLab user ID or username whose activity is summarized together with the GitHub activity (token from $GITLAB_TOKEN)")
+   gitlabURL := flagSet.String("gitlab-url", ghsummary.DefaultGitLabURL, "Root of the GitLab instance")
+   giteaUser := flagSet.String("gitea-user", "", "Gitea username whose activity is summarized together with the GitHub activity (token from $GITEA_TOKEN)")
+   giteaURL := flagSet.String("gitea-url", "", "Root of the Gitea instance, e.g. https://git.example.com")
+   record := flagSet.String("record", "", "Save every GitHub response into this directory, to reproduce the run later with --replay")
+   replay := flagSet.String("replay", "", "Serve GitHub responses from a directory written by --record instead of the network")
+   timeout := flagSet.Duration("timeout", 0, "Abort the run after this duration (e.g. 90s, 5m). 0 means no limit")
    flagSet.Parse(os.Args[1:])
 
 	// Sanitize inputs
-	if !utils.SanitizeInputs(*username, *outputFile) {
-		log.Fatalf("Usage: %s --username <username> --output <outputFile> --max-events <maxEvents>", os.Args[0])
+	members := ghsummary.SplitPatterns(*users)
+	teamMode := len(members) > 0 || *team != ""
+	if *org != "" {
+		if !utils.SanitizeUsername(*org) || !utils.SanitizeOutputFile(*outputFile) {
+			log.Fatalf("Usage: %s --org <org> --output <outputFile> --max-events <maxEvents>", os.Args[0])
+		}
+	} else if *repo != "" {
+		if !utils.SanitizeRepo(*repo) || !utils.SanitizeOutputFile(*outputFile) {
+			log.Fatalf("Usage: %s --repo <owner/name> --output <outputFile> --max-events <maxEvents>", os.Args[0])
+		}
+	} else if teamMode {
+		if !utils.SanitizeOutputFile(*outputFile) || (*team != "" && !utils.SanitizeTeamSlug(*team)) {
+			log.Fatalf("Usage: %s (--users <user1,user2> | --team <org/team>) --output <outputFile> --max-events <maxEvents>", os.Args[0])
+		}
+	} else if !utils.SanitizeInputs(*username, *outputFile) || (*source != "rest" && *source != "graphql") {
+		log.Fatalf("Usage: %s --username <username> --output <outputFile> --max-events <maxEvents> [--source rest|graphql]", os.Args[0])
 	}
 
-   log.Printf("Running app with username: %s, output file: %s, max events: %d, pronouns: %s", *username, *outputFile, *maxEvents, *pronouns)
+   log.Printf("Running app with username: %s, output file: %s, max events: %d, pronouns: %s, provider: %s", *username, *outputFile, *maxEvents, *pronouns, *provider)
+
+	// Stop in-flight GitHub and LLM requests on Ctrl+C or when the timeout expires
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
 
-	// Fetch GitHub activity
-	activity, err := ghsummary.GetUserActivity(*username, *maxEvents, *mode)
+	summarizer, err := ghsummary.NewSummarizer(*provider)
 	if err != nil {
-		log.Fatalf("Error fetching GitHub activity: %v", err)
+		log.Fatalf("Error creating summarizer: %v", err)
+	}
+	if _, ok := summarizer.(*ghsummary.TemplateSummarizer); ok {
+		summarizer, err = ghsummary.NewTemplateSummarizer(*templates)
+	} else if !*noFallback {
+		summarizer, err = ghsummary.WithTemplateFallback(summarizer, *templates)
+	}
+	if err != nil {
+		log.Fatalf("Error loading templates: %v", err)
+	}
+	window, err := ghsummary.ParseTimeWindow(*since, *until, time.Now())
+	if err != nil {
+		log.Fatalf("Error parsing time window: %v", err)
+	}
+	privatePolicy, err := ghsummary.ParsePrivateRepoPolicy(*private)
+	if err != nil {
+		log.Fatalf("Error parsing private repository policy: %v", err)
 	}
+	repoFilter := ghsummary.RepoFilter{
+		IncludeRepos: ghsummary.SplitPatterns(*includeRepos),
+		ExcludeRepos: ghsummary.SplitPatterns(*excludeRepos),
+		IncludeOrgs:  ghsummary.SplitPatterns(*includeOrgs),
+		ExcludeOrgs:  ghsummary.SplitPatterns(*excludeOrgs),
+		SkipForks:    *skipForks,
+		SkipArchived: *skipArchived,
+		SkipTopics:   ghsummary.SplitPatterns(*skipTopics),
+		Private:      privatePolicy,
+	}
+	emails, err := ghsummary.ParseAuthorEmails(*authorEmails, *username)
+	if err != nil {
+		log.Fatalf("Error parsing author emails: %v", err)
+	}
+	rateLimiter := ghsummary.NewRateLimiter()
+	rateLimiter.MaxWait = *rateLimitWait
+	opts := ghsummary.Options{Summarizer: summarizer, DisableFallback: *noFallback, TemplateDir: *templates, Pronouns: *pronouns, AuthorEmails: emails, GitHubAPIURL: *githubAPIURL, RateLimiter: rateLimiter, Concurrency: *concurrency, Since: window.Since, Until: window.Until, RepoFilter: repoFilter, MaxRepositories: *maxRepos}
+	if *record != "" && *replay != "" {
+		log.Fatalf("--record and --replay cannot be used together")
+	}
+	if *record != "" {
+		if opts.Record, err = ghsummary.NewHTTPFixtures(*record); err != nil {
+			log.Fatalf("Error opening record directory: %v", err)
+		}
+	}
+	if *replay != "" {
+		opts.Replay = &ghsummary.HTTPFixtures{Dir: *replay}
+	}
+	if *cacheDir != "" {
+		opts.HTTPCache, opts.SummaryCache, err = openCaches(*cacheDir)
+		if err != nil {
+			log.Fatalf("Error opening cache: %v", err)
+		}
+	}
+
+	var summary string
+	if *org != "" {
+		activity, err := ghsummary.GetOrganizationActivity(ctx, *org, *maxEvents, *mode, opts)
+		if err != nil {
+			log.Fatalf("Error fetching GitHub activity: %v", err)
+		}
+		summary, err = ghsummary.GenerateOrganizationSummary(ctx, summarizer, activity, *org)
+		if err != nil {
+			log.Fatalf("Error generating summary: %v", err)
+		}
+	} else if *repo != "" {
+		activity, err := ghsummary.GetRepositoryActivity(ctx, *repo, *maxEvents, *mode, opts)
+		if err != nil {
+			log.Fatalf("Error fetching GitHub activity: %v", err)
+		}
+		summary, err = ghsummary.GenerateRepositorySummary(ctx, summarizer, activity, *repo)
+		if err != nil {
+			log.Fatalf("Error generating summary: %v", err)
+		}
+	} else if teamMode {
+		summary = summarizeTeam(ctx, *team, members, *maxEvents, *mode, opts)
+	} else {
+		// Fetch GitHub activity
+		var activity string
+		if *source == "graphql" {
+			activity, err = ghsummary.GetUserContributions(ctx, *username, opts)
+		} else {
+			sources := []ghsummary.ActivitySource{ghsummary.GitHubSource{Username: *username}}
+			for _, path := range ghsummary.SplitPatterns(*gitRepos) {
+				sources = append(sources, ghsummary.GitRepositorySource{Path: path, Login: *username})
+			}
+			if *gitlabUser != "" {
+				sources = append(sources, ghsummary.GitLabSource{BaseURL: *gitlabURL, User: *gitlabUser, Token: os.Getenv("GITLAB_TOKEN")})
+			}
+			if *giteaUser != "" {
+				sources = append(sources, ghsummary.GiteaSource{BaseURL: *giteaURL, Username: *giteaUser, Token: os.Getenv("GITEA_TOKEN")})
+			}
+			activity, err = ghsummary.GetSourcesActivity(ctx, *username, sources, *maxEvents, *mode, opts)
+		}
+		if err != nil {
+			log.Fatalf("Error fetching GitHub activity: %v", err)
+		}
 
-   // Generate summary using LLM
-   summary, err := ghsummary.GenerateSummary(activity, *pronouns)
-   if err != nil {
-	   log.Fatalf("Error generating summary: %v", err)
-   }
+		// Generate summary using LLM
+		summary, err = ghsummary.GenerateSummary(ctx, summarizer, activity, *pronouns)
+		if err != nil {
+			log.Fatalf("Error generating summary: %v", err)
+		}
+	}
 
 	// Generate SVG from summary
-	err = ghsummary.GenerateSVGFile(summary, *outputFile)
+	err = ghsummary.GenerateSVGFile(ctx, summary, *outputFile, window)
 	if err != nil {
 		log.Fatalf("Error generating SVG: %v", err)
 	}
 
 	fmt.Printf("Summary SVG generated: %s\n", *outputFile)
+	fmt.Printf("GitHub API usage: %s\n", rateLimiter.Stats())
+}
+
+// summarizeTeam summarizes the given users together with the members of the
+// team slug, if any.
+func summarizeTeam(ctx context.Context, team string, members []string, maxEvents int, mode string, opts ghsummary.Options) string {
+	if team != "" {
+		resolved, err := ghsummary.GetTeamMembers(ctx, team, opts)
+		if err != nil {
+			log.Fatalf("Error fetching members of %s: %v", team, err)
+		}
+		members = append(members, resolved...)
+	}
+	for _, member := range members {
+		if !utils.SanitizeUsername(member) {
+			log.Fatalf("Invalid team member: %s", member)
+		}
+	}
+	log.Printf("Summarizing %d team members: %s", len(members), strings.Join(members, ", "))
+
+	activity, err := ghsummary.GetTeamActivity(ctx, team, members, maxEvents, mode, opts)
+	if err != nil {
+		log.Fatalf("Error fetching GitHub activity: %v", err)
+	}
+	summary, err := ghsummary.GenerateTeamSummary(ctx, opts.Summarizer, activity, team)
+	if err != nil {
+		log.Fatalf("Error generating summary: %v", err)
+	}
+	return summary
+}
+
+// openCaches opens the HTTP and commit summary caches under dir.
+func openCaches(dir string) (*ghsummary.HTTPCache, *ghsummary.SummaryCache, error) {
+	httpCache, err := ghsummary.NewHTTPCache(filepath.Join(dir, "http"))
+	if err != nil {
+		return nil, nil, err
+	}
+	summaryCache, err := ghsummary.NewSummaryCache(filepath.Join(dir, "summaries"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return httpCache, summaryCache, nil
+}
+
+// runCacheCommand handles "cache prune", which removes cache entries that
+// have not been used recently.
+func runCacheCommand(args []string) {
+	if len(args) == 0 || args[0] != "prune" {
+		log.Fatalf("Usage: %s cache prune --cache-dir <dir> [--older-than <duration>]", os.Args[0])
+	}
+	flagSet := flag.NewFlagSet("cache prune", flag.ExitOnError)
+	cacheDir := flagSet.String("cache-dir", "", "Cache directory to prune")
+	olderThan := flagSet.Duration("older-than", 30*24*time.Hour, "Remove entries not used for this long")
+	flagSet.Parse(args[1:])
+	if *cacheDir == "" {
+		log.Fatalf("Usage: %s cache prune --cache-dir <dir> [--older-than <duration>]", os.Args[0])
+	}
+
+	httpCache, summaryCache, err := openCaches(*cacheDir)
+	if err != nil {
+		log.Fatalf("Error opening cache: %v", err)
+	}
+	cutoff := time.Now().Add(-*olderThan)
+	responses, err := httpCache.Prune(cutoff)
+	if err != nil {
+		log.Fatalf("Error pruning HTTP cache: %v", err)
+	}
+	summaries, err := summaryCache.Prune(cutoff)
+	if err != nil {
+		log.Fatalf("Error pruning summary cache: %v", err)
+	}
+	fmt.Printf("Removed %d cached GitHub responses and %d commit summaries\n", responses, summaries)
 }
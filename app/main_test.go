@@ -2,9 +2,15 @@ package main
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
+// replayDir holds the GitHub responses recorded for the user octo, relative
+// to the ./test directory the app runs in.
+const replayDir = "../../testdata/replay/octo"
+
 func RunMainApp(t *testing.T) {
 	if _, err := os.Stat("./test"); os.IsNotExist(err) {
 		// Create the directory if it doesn't exist
@@ -30,15 +36,46 @@ func RunMainApp(t *testing.T) {
 	}
 }
 
+// readOutput returns the SVG the app wrote into ./test.
+func readOutput(t *testing.T, name string) string {
+	t.Helper()
+	svg, err := os.ReadFile(filepath.Join("test", name))
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	return string(svg)
+}
+
 func TestMainApp(t *testing.T) {
-	// Simulate command-line arguments
-	os.Args = []string{"app", "--username", "McCzarny"}
+	// Replay GitHub and summarize with the fake backend, so the whole
+	// pipeline runs offline and deterministically.
+	prompts := t.TempDir()
+	t.Setenv("GHSUMMARY_FAKE_PROMPTS", prompts)
+	os.Args = []string{"app", "--username", "octo", "--replay", replayDir, "--provider", "fake"}
 	RunMainApp(t)
+
+	svg := readOutput(t, "summary.svg")
+	if !strings.Contains(svg, "Fake summary of octo: 4 activities") {
+		t.Errorf("expected the fake summary in the SVG:\n%s", svg)
+	}
+	files, err := filepath.Glob(filepath.Join(prompts, "summary-*.txt"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected the summary prompt to be recorded, got %v, %v", files, err)
+	}
+	prompt, err := os.ReadFile(files[0])
+	if err != nil || !strings.Contains(string(prompt), "Recent activities for user octo:") {
+		t.Errorf("unexpected prompt %q, %v", prompt, err)
+	}
 }
 
 func TestStrictMode(t *testing.T) {
-	// Simulate command-line arguments
-	os.Args = []string{"app", "--username", "McCzarny", "--output", "strict-summary.svg", "--max-events", "100", "--mode", "strict"}
+	t.Setenv("GHSUMMARY_PROVIDER", "fake")
+	os.Args = []string{"app", "--username", "octo", "--output", "strict-summary.svg", "--max-events", "100", "--mode", "strict", "--replay", replayDir}
 	// Check if ./test directory exists
 	RunMainApp(t)
+
+	svg := readOutput(t, "strict-summary.svg")
+	if !strings.Contains(svg, "Fake summary of octo") {
+		t.Errorf("expected the fake summary in the SVG:\n%s", svg)
+	}
 }
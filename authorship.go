@@ -0,0 +1,116 @@
+package ghsummary
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CommitAuthorship tells how a commit relates to the user whose activity is
+// being summarized.
+type CommitAuthorship struct {
+	// ByUser is set when the user authored, committed or co-authored the commit.
+	ByUser bool
+	// Merge is set for commits with more than one parent.
+	Merge bool
+	// BotAuthored is set when the commit author is a bot account.
+	BotAuthored bool
+	// BotCoAuthored is set when a Co-authored-by trailer names a bot.
+	BotCoAuthored bool
+	// Unlinked is set when the commit was not matched to the user and its
+	// author is linked to no GitHub account, neither directly nor through a
+	// noreply address or authorEmails, so the real author is unknown.
+	Unlinked bool
+}
+
+var coAuthoredByPattern = regexp.MustCompile(`(?im)^co-authored-by:\s*(.*?)\s*<([^>]+)>\s*$`)
+
+// ClassifyCommit determines whether the commit belongs to login. A commit
+// belongs to the user when the linked author or committer account is theirs,
+// when the author, committer or a co-author email is one of their GitHub
+// noreply addresses, or when the email is mapped to them in authorEmails.
+func ClassifyCommit(commit RepoCommit, login string, authorEmails map[string]string) CommitAuthorship {
+	authorship := CommitAuthorship{Merge: len(commit.Parents) > 1}
+
+	isUser := func(account *User, email string) bool {
+		if account != nil && login != "" && strings.EqualFold(account.Login, login) {
+			return true
+		}
+		return isUserEmail(email, login, authorEmails)
+	}
+
+	authorship.BotAuthored = isBotAccount(commit.Author, commit.Commit.Author.Name, commit.Commit.Author.Email)
+	authorship.ByUser = isUser(commit.Author, commit.Commit.Author.Email) || isUser(commit.Committer, commit.Commit.Committer.Email)
+
+	for _, match := range coAuthoredByPattern.FindAllStringSubmatch(commit.Commit.Message, -1) {
+		name, email := match[1], match[2]
+		if isBotAccount(nil, name, email) {
+			authorship.BotCoAuthored = true
+		}
+		if isUserEmail(email, login, authorEmails) {
+			authorship.ByUser = true
+		}
+	}
+	_, otherAccount := emailLogin(commit.Commit.Author.Email, authorEmails)
+	authorship.Unlinked = !authorship.ByUser && commit.Author == nil && !otherAccount && !authorship.BotAuthored
+	return authorship
+}
+
+// isUserEmail reports whether the commit email belongs to login, either as
+// one of GitHub's noreply addresses or through the configured mapping.
+func isUserEmail(email, login string, authorEmails map[string]string) bool {
+	owner, ok := emailLogin(email, authorEmails)
+	return ok && login != "" && strings.EqualFold(owner, login)
+}
+
+// emailLogin returns the login a commit email belongs to, when it is one of
+// GitHub's noreply addresses or mapped in authorEmails.
+func emailLogin(email string, authorEmails map[string]string) (string, bool) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", false
+	}
+	for mappedEmail, mappedLogin := range authorEmails {
+		if strings.EqualFold(mappedEmail, email) {
+			return mappedLogin, true
+		}
+	}
+	// <login>@users.noreply.github.com or <id>+<login>@users.noreply.github.com
+	local, found := strings.CutSuffix(email, "@users.noreply.github.com")
+	if !found {
+		return "", false
+	}
+	if _, after, ok := strings.Cut(local, "+"); ok {
+		local = after
+	}
+	return local, true
+}
+
+func isBotAccount(account *User, name, email string) bool {
+	if account != nil && (account.Type == "Bot" || strings.HasSuffix(account.Login, "[bot]")) {
+		return true
+	}
+	return strings.HasSuffix(name, "[bot]") || strings.Contains(strings.ToLower(email), "[bot]@")
+}
+
+// ParseAuthorEmails parses a comma-separated list of extra commit emails.
+// Each entry is either "email", mapped to defaultLogin, or "email=login".
+func ParseAuthorEmails(spec string, defaultLogin string) (map[string]string, error) {
+	emails := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		email, login, found := strings.Cut(entry, "=")
+		if !found {
+			login = defaultLogin
+		}
+		email, login = strings.TrimSpace(email), strings.TrimSpace(login)
+		if !strings.Contains(email, "@") || login == "" {
+			return nil, fmt.Errorf("invalid author email entry %q", entry)
+		}
+		emails[strings.ToLower(email)] = login
+	}
+	return emails, nil
+}
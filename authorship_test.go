@@ -0,0 +1,74 @@
+package ghsummary
+
+import (
+	"strings"
+	"testing"
+)
+
+func newTestCommit(authorLogin, authorEmail, message string, parents int) RepoCommit {
+	var commit RepoCommit
+	commit.Commit.Message = message
+	commit.Commit.Author.Email = authorEmail
+	if authorLogin != "" {
+		commit.Author = &User{Login: authorLogin}
+		if strings.HasSuffix(authorLogin, "[bot]") {
+			commit.Author.Type = "Bot"
+		}
+	}
+	for range parents {
+		commit.Parents = append(commit.Parents, struct {
+			SHA string `json:"sha"`
+		}{})
+	}
+	return commit
+}
+
+func TestClassifyCommit(t *testing.T) {
+	emails := map[string]string{"Work@Example.com": "McCzarny"}
+	tests := []struct {
+		name   string
+		commit RepoCommit
+		want   CommitAuthorship
+	}{
+		{"linked account", newTestCommit("mcczarny", "", "Fix bug", 1), CommitAuthorship{ByUser: true}},
+		{"noreply email", newTestCommit("", "123+McCzarny@users.noreply.github.com", "Fix bug", 1), CommitAuthorship{ByUser: true}},
+		{"mapped email", newTestCommit("", "work@example.com", "Fix bug", 1), CommitAuthorship{ByUser: true}},
+		{"other account", newTestCommit("someone", "someone@example.com", "Fix bug", 1), CommitAuthorship{}},
+		{"unlinked email", newTestCommit("", "laptop@localhost", "Fix bug", 1), CommitAuthorship{Unlinked: true}},
+		{"noreply email of another account", newTestCommit("", "7+someone@users.noreply.github.com", "Fix bug", 1), CommitAuthorship{}},
+		{"merge", newTestCommit("McCzarny", "", "Merge branch 'main'", 2), CommitAuthorship{ByUser: true, Merge: true}},
+		{"bot author", newTestCommit("dependabot[bot]", "", "Bump deps", 1), CommitAuthorship{BotAuthored: true}},
+		{
+			"bot co-author",
+			newTestCommit("McCzarny", "", "Add feature\n\nCo-authored-by: Copilot[bot] <copilot[bot]@users.noreply.github.com>", 1),
+			CommitAuthorship{ByUser: true, BotCoAuthored: true},
+		},
+		{
+			"user co-author",
+			newTestCommit("someone", "", "Pair work\n\nCo-authored-by: Maciej <McCzarny@users.noreply.github.com>", 1),
+			CommitAuthorship{ByUser: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyCommit(tt.commit, "McCzarny", emails); got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseAuthorEmails(t *testing.T) {
+	emails, err := ParseAuthorEmails("me@home.dev, Work@Example.com=other ,", "McCzarny")
+	if err != nil {
+		t.Fatalf("ParseAuthorEmails failed: %v", err)
+	}
+	if emails["me@home.dev"] != "McCzarny" || emails["work@example.com"] != "other" || len(emails) != 2 {
+		t.Errorf("unexpected emails: %v", emails)
+	}
+
+	if _, err := ParseAuthorEmails("not-an-email", "McCzarny"); err == nil {
+		t.Errorf("expected an error for an invalid entry")
+	}
+}
@@ -0,0 +1,397 @@
+package ghsummary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Event is a single entry of the GitHub Events API. Payload holds one of the
+// *...EventPayload types below, selected by Type, or nil when the type is not
+// known or the payload could not be decoded.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Actor      Actor           `json:"actor"`
+	Repo       EventRepo       `json:"repo"`
+	Org        *Actor          `json:"org,omitempty"`
+	Public     bool            `json:"public"`
+	CreatedAt  time.Time       `json:"created_at"`
+	RawPayload json.RawMessage `json:"payload"`
+	Payload    any             `json:"-"`
+}
+
+// Actor is the user or organization an event is attributed to.
+type Actor struct {
+	ID           int64  `json:"id"`
+	Login        string `json:"login"`
+	DisplayLogin string `json:"display_login,omitempty"`
+	URL          string `json:"url"`
+	AvatarURL    string `json:"avatar_url"`
+}
+
+// EventRepo is the short repository reference attached to every event.
+type EventRepo struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// User is a GitHub account as embedded in payload objects.
+type User struct {
+	ID      int64  `json:"id"`
+	Login   string `json:"login"`
+	Type    string `json:"type"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Repository is a full repository object, e.g. the forkee of a ForkEvent.
+type Repository struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	FullName    string   `json:"full_name"`
+	Description string   `json:"description"`
+	HTMLURL     string   `json:"html_url"`
+	Private     bool     `json:"private"`
+	Fork        bool     `json:"fork"`
+	Archived    bool     `json:"archived"`
+	Topics      []string `json:"topics"`
+	Owner       User     `json:"owner"`
+}
+
+type Label struct {
+	Name string `json:"name"`
+}
+
+// Issue is an issue or, when PullRequest is set, the issue side of a pull request.
+type Issue struct {
+	Number      int               `json:"number"`
+	Title       string            `json:"title"`
+	Body        string            `json:"body"`
+	State       string            `json:"state"`
+	StateReason string            `json:"state_reason"`
+	HTMLURL     string            `json:"html_url"`
+	User        User              `json:"user"`
+	Labels      []Label           `json:"labels"`
+	Comments    int               `json:"comments"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ClosedAt    *time.Time        `json:"closed_at"`
+	PullRequest *IssuePullRequest `json:"pull_request,omitempty"`
+}
+
+// IssuePullRequest links an issue to the pull request it represents.
+type IssuePullRequest struct {
+	URL      string     `json:"url"`
+	MergedAt *time.Time `json:"merged_at"`
+}
+
+// Comment is an issue, commit or review comment. Path, DiffHunk and CommitID
+// are only set for comments attached to code.
+type Comment struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	HTMLURL   string    `json:"html_url"`
+	User      User      `json:"user"`
+	Path      string    `json:"path,omitempty"`
+	DiffHunk  string    `json:"diff_hunk,omitempty"`
+	CommitID  string    `json:"commit_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PullRequestBranch struct {
+	Ref  string      `json:"ref"`
+	SHA  string      `json:"sha"`
+	Repo *Repository `json:"repo"`
+}
+
+type PullRequest struct {
+	URL          string            `json:"url"`
+	Number       int               `json:"number"`
+	Title        string            `json:"title"`
+	Body         string            `json:"body"`
+	State        string            `json:"state"`
+	HTMLURL      string            `json:"html_url"`
+	User         User              `json:"user"`
+	Draft        bool              `json:"draft"`
+	Merged       bool              `json:"merged"`
+	MergedAt     *time.Time        `json:"merged_at"`
+	Commits      int               `json:"commits"`
+	Additions    int               `json:"additions"`
+	Deletions    int               `json:"deletions"`
+	ChangedFiles int               `json:"changed_files"`
+	Head         PullRequestBranch `json:"head"`
+	Base         PullRequestBranch `json:"base"`
+}
+
+type Review struct {
+	ID          int64     `json:"id"`
+	Body        string    `json:"body"`
+	State       string    `json:"state"`
+	HTMLURL     string    `json:"html_url"`
+	User        User      `json:"user"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+type Release struct {
+	ID          int64     `json:"id"`
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	HTMLURL     string    `json:"html_url"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+type Discussion struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+type WikiPage struct {
+	PageName string `json:"page_name"`
+	Title    string `json:"title"`
+	Action   string `json:"action"`
+	HTMLURL  string `json:"html_url"`
+}
+
+// PushCommit is a commit listed inline in a PushEvent payload.
+type PushCommit struct {
+	SHA    string `json:"sha"`
+	Author struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"author"`
+	Message  string `json:"message"`
+	Distinct bool   `json:"distinct"`
+	URL      string `json:"url"`
+}
+
+type CommitCommentEventPayload struct {
+	Action  string  `json:"action"`
+	Comment Comment `json:"comment"`
+}
+
+type CreateEventPayload struct {
+	Ref          string `json:"ref"`
+	RefType      string `json:"ref_type"`
+	MasterBranch string `json:"master_branch"`
+	Description  string `json:"description"`
+	PusherType   string `json:"pusher_type"`
+}
+
+type DeleteEventPayload struct {
+	Ref        string `json:"ref"`
+	RefType    string `json:"ref_type"`
+	PusherType string `json:"pusher_type"`
+}
+
+type DiscussionEventPayload struct {
+	Action     string     `json:"action"`
+	Discussion Discussion `json:"discussion"`
+}
+
+type ForkEventPayload struct {
+	Forkee Repository `json:"forkee"`
+}
+
+type GollumEventPayload struct {
+	Pages []WikiPage `json:"pages"`
+}
+
+type IssueCommentEventPayload struct {
+	Action  string  `json:"action"`
+	Issue   Issue   `json:"issue"`
+	Comment Comment `json:"comment"`
+}
+
+type IssuesEventPayload struct {
+	Action   string `json:"action"`
+	Issue    Issue  `json:"issue"`
+	Assignee *User  `json:"assignee,omitempty"`
+	Label    *Label `json:"label,omitempty"`
+}
+
+type MemberEventPayload struct {
+	Action string `json:"action"`
+	Member User   `json:"member"`
+}
+
+type PublicEventPayload struct{}
+
+type PullRequestEventPayload struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+}
+
+type PullRequestReviewEventPayload struct {
+	Action      string      `json:"action"`
+	Review      Review      `json:"review"`
+	PullRequest PullRequest `json:"pull_request"`
+}
+
+type PullRequestReviewCommentEventPayload struct {
+	Action      string      `json:"action"`
+	Comment     Comment     `json:"comment"`
+	PullRequest PullRequest `json:"pull_request"`
+}
+
+type PullRequestReviewThreadEventPayload struct {
+	Action      string      `json:"action"`
+	PullRequest PullRequest `json:"pull_request"`
+	Thread      struct {
+		Comments []Comment `json:"comments"`
+	} `json:"thread"`
+}
+
+type PushEventPayload struct {
+	RepositoryID int64        `json:"repository_id"`
+	PushID       int64        `json:"push_id"`
+	Size         int          `json:"size"`
+	DistinctSize int          `json:"distinct_size"`
+	Ref          string       `json:"ref"`
+	Head         string       `json:"head"`
+	Before       string       `json:"before"`
+	Commits      []PushCommit `json:"commits"`
+}
+
+type ReleaseEventPayload struct {
+	Action  string  `json:"action"`
+	Release Release `json:"release"`
+}
+
+type SponsorshipEventPayload struct {
+	Action        string `json:"action"`
+	EffectiveDate string `json:"effective_date"`
+}
+
+type WatchEventPayload struct {
+	Action string `json:"action"`
+}
+
+// payloadTypes maps event types to constructors of their payload structs.
+var payloadTypes = map[string]func() any{
+	"CommitCommentEvent":            func() any { return &CommitCommentEventPayload{} },
+	"CreateEvent":                   func() any { return &CreateEventPayload{} },
+	"DeleteEvent":                   func() any { return &DeleteEventPayload{} },
+	"DiscussionEvent":               func() any { return &DiscussionEventPayload{} },
+	"ForkEvent":                     func() any { return &ForkEventPayload{} },
+	"GollumEvent":                   func() any { return &GollumEventPayload{} },
+	"IssueCommentEvent":             func() any { return &IssueCommentEventPayload{} },
+	"IssuesEvent":                   func() any { return &IssuesEventPayload{} },
+	"MemberEvent":                   func() any { return &MemberEventPayload{} },
+	"PublicEvent":                   func() any { return &PublicEventPayload{} },
+	"PullRequestEvent":              func() any { return &PullRequestEventPayload{} },
+	"PullRequestReviewEvent":        func() any { return &PullRequestReviewEventPayload{} },
+	"PullRequestReviewCommentEvent": func() any { return &PullRequestReviewCommentEventPayload{} },
+	"PullRequestReviewThreadEvent":  func() any { return &PullRequestReviewThreadEventPayload{} },
+	"PushEvent":                     func() any { return &PushEventPayload{} },
+	"ReleaseEvent":                  func() any { return &ReleaseEventPayload{} },
+	"SponsorshipEvent":              func() any { return &SponsorshipEventPayload{} },
+	"WatchEvent":                    func() any { return &WatchEventPayload{} },
+}
+
+// validate reports payload fields that are required for processing but
+// missing. Payload types without required fields do not implement it.
+func (p *PushEventPayload) validate() error {
+	if p.Head == "" {
+		return errors.New("missing head")
+	}
+	if p.Before == "" {
+		return errors.New("missing before")
+	}
+	return nil
+}
+
+func (p *IssueCommentEventPayload) validate() error {
+	if p.Action == "" {
+		return errors.New("missing action")
+	}
+	if p.Issue.Title == "" {
+		return errors.New("missing issue title")
+	}
+	return nil
+}
+
+// DiagnosticKind classifies problems found while decoding events.
+type DiagnosticKind string
+
+const (
+	// DiagnosticMalformedEvent means the event envelope itself could not be decoded.
+	DiagnosticMalformedEvent DiagnosticKind = "malformed_event"
+	// DiagnosticUnknownType means the event type has no payload model.
+	DiagnosticUnknownType DiagnosticKind = "unknown_type"
+	// DiagnosticMalformedPayload means the payload did not match its model.
+	DiagnosticMalformedPayload DiagnosticKind = "malformed_payload"
+	// DiagnosticMissingField means the payload decoded but lacks a required field.
+	DiagnosticMissingField DiagnosticKind = "missing_field"
+)
+
+// EventDiagnostic describes an event that could not be fully decoded.
+type EventDiagnostic struct {
+	Index     int
+	EventID   string
+	EventType string
+	Kind      DiagnosticKind
+	Err       error
+}
+
+func (d EventDiagnostic) String() string {
+	return fmt.Sprintf("event #%d [%s] %s: %s: %v", d.Index, d.EventID, d.EventType, d.Kind, d.Err)
+}
+
+// DecodeEvents decodes an Events API response. Events that cannot be decoded
+// completely are reported as diagnostics instead of failing the whole page:
+// events with a malformed envelope are dropped, while events with an unknown
+// or malformed payload are kept with a nil Payload. An error is returned only
+// when the response is not a JSON array.
+func DecodeEvents(r io.Reader) ([]Event, []EventDiagnostic, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, nil, err
+	}
+
+	events := make([]Event, 0, len(raw))
+	var diagnostics []EventDiagnostic
+	for i, data := range raw {
+		var event Event
+		if err := json.Unmarshal(data, &event); err != nil {
+			diagnostics = append(diagnostics, EventDiagnostic{Index: i, Kind: DiagnosticMalformedEvent, Err: err})
+			continue
+		}
+
+		payload, kind, err := DecodePayload(event.Type, event.RawPayload)
+		event.Payload = payload
+		if err != nil {
+			diagnostics = append(diagnostics, EventDiagnostic{Index: i, EventID: event.ID, EventType: event.Type, Kind: kind, Err: err})
+		}
+		events = append(events, event)
+	}
+	return events, diagnostics, nil
+}
+
+// DecodePayload decodes the raw payload of the given event type into its
+// typed model. On failure the returned kind tells what went wrong; a payload
+// that is only missing required fields is still returned.
+func DecodePayload(eventType string, raw json.RawMessage) (any, DiagnosticKind, error) {
+	newPayload, ok := payloadTypes[eventType]
+	if !ok {
+		return nil, DiagnosticUnknownType, fmt.Errorf("unsupported event type %q", eventType)
+	}
+
+	payload := newPayload()
+	if err := json.Unmarshal(raw, payload); err != nil {
+		return nil, DiagnosticMalformedPayload, err
+	}
+	if v, ok := payload.(interface{ validate() error }); ok {
+		if err := v.validate(); err != nil {
+			return payload, DiagnosticMissingField, err
+		}
+	}
+	return payload, "", nil
+}
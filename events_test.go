@@ -0,0 +1,75 @@
+package ghsummary
+
+import (
+	"strings"
+	"testing"
+)
+
+const eventsFixture = `[
+  {"id": "1", "type": "PushEvent", "repo": {"name": "McCzarny/ghsummary"}, "created_at": "2026-07-01T10:00:00Z",
+   "payload": {"ref": "refs/heads/master", "before": "aaa", "head": "bbb"}},
+  {"id": "2", "type": "IssueCommentEvent", "repo": {"name": "McCzarny/ghsummary"}, "created_at": "2026-07-01T09:00:00Z",
+   "payload": {"action": "created", "issue": {"number": 7, "title": "Crash on start", "body": "Steps..."}, "comment": {"body": "Fixed in master"}}},
+  {"id": "3", "type": "BrandNewEvent", "repo": {"name": "McCzarny/ghsummary"}, "payload": {}},
+  {"id": "4", "type": "PushEvent", "repo": {"name": "McCzarny/ghsummary"}, "payload": {"head": 42}},
+  {"id": "5", "type": "PushEvent", "repo": {"name": "McCzarny/ghsummary"}, "payload": {"before": "aaa", "commits": [{"message": "Inline commit"}]}},
+  {"id": 6, "type": "WatchEvent"}
+]`
+
+func TestDecodeEvents(t *testing.T) {
+	events, diagnostics, err := DecodeEvents(strings.NewReader(eventsFixture))
+	if err != nil {
+		t.Fatalf("DecodeEvents failed: %v", err)
+	}
+
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events, got %d", len(events))
+	}
+
+	push, ok := events[0].Payload.(*PushEventPayload)
+	if !ok {
+		t.Fatalf("expected *PushEventPayload, got %T", events[0].Payload)
+	}
+	if push.Before != "aaa" || push.Head != "bbb" || events[0].Repo.Name != "McCzarny/ghsummary" {
+		t.Errorf("unexpected push event: %+v %+v", events[0], push)
+	}
+	if events[0].CreatedAt.IsZero() {
+		t.Errorf("expected created_at to be decoded")
+	}
+
+	comment, ok := events[1].Payload.(*IssueCommentEventPayload)
+	if !ok {
+		t.Fatalf("expected *IssueCommentEventPayload, got %T", events[1].Payload)
+	}
+	if comment.Issue.Title != "Crash on start" || comment.Comment.Body != "Fixed in master" {
+		t.Errorf("unexpected issue comment payload: %+v", comment)
+	}
+
+	wantKinds := map[string]DiagnosticKind{
+		"3": DiagnosticUnknownType,
+		"4": DiagnosticMalformedPayload,
+		"5": DiagnosticMissingField,
+		"":  DiagnosticMalformedEvent,
+	}
+	if len(diagnostics) != len(wantKinds) {
+		t.Fatalf("expected %d diagnostics, got %v", len(wantKinds), diagnostics)
+	}
+	for _, diagnostic := range diagnostics {
+		if want := wantKinds[diagnostic.EventID]; diagnostic.Kind != want {
+			t.Errorf("event %q: expected %s, got %s", diagnostic.EventID, want, diagnostic.Kind)
+		}
+	}
+
+	if events[2].Payload != nil || events[3].Payload != nil {
+		t.Errorf("expected nil payloads for unknown and malformed events")
+	}
+	if _, ok := events[4].Payload.(*PushEventPayload); !ok {
+		t.Errorf("expected payload with missing fields to be kept, got %T", events[4].Payload)
+	}
+}
+
+func TestDecodeEventsRejectsNonArray(t *testing.T) {
+	if _, _, err := DecodeEvents(strings.NewReader(`{"message": "Not Found"}`)); err == nil {
+		t.Fatalf("expected an error for a non-array response")
+	}
+}
@@ -0,0 +1,193 @@
+package ghsummary
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FakeSummarizer is a deterministic summarizer that never calls an LLM, for
+// tests and offline previews. It answers from fixture files when one exists
+// for the request and from a fixed template otherwise, and it records every
+// prompt it receives. Select it with the "fake" provider, configured by
+// GHSUMMARY_FAKE_FIXTURES and GHSUMMARY_FAKE_PROMPTS.
+type FakeSummarizer struct {
+	// FixtureDir holds canned summaries. A request of a kind ("summary",
+	// "team", "repository", "organization" or "commit") about a subject is
+	// answered with "<kind>-<subject>.txt", falling back to "<kind>.txt".
+	// Slashes in the subject are replaced by underscores. Empty uses only
+	// the template.
+	FixtureDir string
+	// PromptDir, when set, receives every prompt as "<kind>-<hash>.txt",
+	// with the system prompt a real backend would have been given.
+	PromptDir string
+
+	mu      sync.Mutex
+	prompts []FakePrompt
+}
+
+// FakePrompt is a prompt received by a FakeSummarizer.
+type FakePrompt struct {
+	Kind         string
+	Subject      string
+	SystemPrompt string
+	Content      string
+}
+
+// NewFakeSummarizer creates a fake backend configured from
+// GHSUMMARY_FAKE_FIXTURES and GHSUMMARY_FAKE_PROMPTS.
+func NewFakeSummarizer() (*FakeSummarizer, error) {
+	fake := &FakeSummarizer{
+		FixtureDir: os.Getenv("GHSUMMARY_FAKE_FIXTURES"),
+		PromptDir:  os.Getenv("GHSUMMARY_FAKE_PROMPTS"),
+	}
+	if fake.PromptDir != "" {
+		if err := os.MkdirAll(fake.PromptDir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating prompt directory: %w", err)
+		}
+	}
+	return fake, nil
+}
+
+// Prompts returns the prompts received so far, in the order they arrived.
+func (f *FakeSummarizer) Prompts() []FakePrompt {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakePrompt(nil), f.prompts...)
+}
+
+func (f *FakeSummarizer) Summarize(ctx context.Context, activity string, pronouns string) (string, error) {
+	return f.respond(FakePrompt{Kind: "summary", Subject: activitySubject(activity), SystemPrompt: fmt.Sprintf(SystemPromptSummary, pronouns), Content: activity})
+}
+
+func (f *FakeSummarizer) SummarizeTeam(ctx context.Context, activity string, team string) (string, error) {
+	return f.respond(FakePrompt{Kind: "team", Subject: team, SystemPrompt: fmt.Sprintf(SystemPromptTeamSummary, team), Content: activity})
+}
+
+func (f *FakeSummarizer) SummarizeRepository(ctx context.Context, activity string, repo string) (string, error) {
+	return f.respond(FakePrompt{Kind: "repository", Subject: repo, SystemPrompt: fmt.Sprintf(SystemPromptRepositorySummary, repo), Content: activity})
+}
+
+func (f *FakeSummarizer) SummarizeOrganization(ctx context.Context, activity string, org string) (string, error) {
+	return f.respond(FakePrompt{Kind: "organization", Subject: org, SystemPrompt: fmt.Sprintf(SystemPromptOrganizationSummary, org), Content: activity})
+}
+
+func (f *FakeSummarizer) SummarizeCommit(ctx context.Context, content string) (string, error) {
+	return f.respond(FakePrompt{Kind: "commit", SystemPrompt: SystemPromptSummaryCommit, Content: content})
+}
+
+func (f *FakeSummarizer) CommitModelName() string {
+	return "fake"
+}
+
+func (f *FakeSummarizer) respond(prompt FakePrompt) (string, error) {
+	f.mu.Lock()
+	f.prompts = append(f.prompts, prompt)
+	f.mu.Unlock()
+
+	if f.PromptDir != "" {
+		hash := sha256.Sum256([]byte(prompt.SystemPrompt + "\n" + prompt.Content))
+		name := fmt.Sprintf("%s-%s.txt", prompt.Kind, hex.EncodeToString(hash[:])[:12])
+		data := fmt.Sprintf("System:\n%s\n\nUser:\n%s", prompt.SystemPrompt, prompt.Content)
+		if err := writeFileAtomic(filepath.Join(f.PromptDir, name), []byte(data)); err != nil {
+			return "", fmt.Errorf("recording prompt: %w", err)
+		}
+	}
+
+	summary, err := f.fixture(prompt)
+	if err != nil {
+		return "", err
+	}
+	if summary == "" {
+		summary = fakeTemplateSummary(prompt)
+	}
+	return summary, nil
+}
+
+// fixture returns the canned summary for the prompt, or an empty string if
+// there is none.
+func (f *FakeSummarizer) fixture(prompt FakePrompt) (string, error) {
+	if f.FixtureDir == "" {
+		return "", nil
+	}
+	var names []string
+	if prompt.Subject != "" {
+		names = append(names, fmt.Sprintf("%s-%s.txt", prompt.Kind, strings.ReplaceAll(prompt.Subject, "/", "_")))
+	}
+	names = append(names, prompt.Kind+".txt")
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(f.FixtureDir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return "", nil
+}
+
+// activitySubject extracts the user from the first line of a prompt built
+// by GetUserActivity, e.g. "Recent activities for user octo:".
+func activitySubject(activity string) string {
+	first, _, _ := strings.Cut(activity, "\n")
+	first = strings.TrimSuffix(strings.TrimSpace(first), ":")
+	if i := strings.LastIndex(first, " user "); i >= 0 {
+		return first[i+len(" user "):]
+	}
+	return ""
+}
+
+// fakeTemplateSummary describes the prompt by counting its activities, so
+// the summary changes exactly when the collected activity does.
+func fakeTemplateSummary(prompt FakePrompt) string {
+	if prompt.Kind == "commit" {
+		message, _, _ := strings.Cut(strings.TrimPrefix(prompt.Content, "Commit message: "), "\n")
+		return fmt.Sprintf("Fake summary of the commit %q.", message)
+	}
+
+	types := make(map[string]int)
+	repositories := make(map[string]bool)
+	activities := 0
+	for _, line := range strings.Split(prompt.Content, "\n") {
+		if activityType, ok := strings.CutPrefix(line, "Type: "); ok {
+			types[activityType]++
+			activities++
+		}
+		if repo, ok := strings.CutPrefix(line, "Repository: "); ok {
+			repositories[repo] = true
+		}
+	}
+	counts := make([]string, 0, len(types))
+	for activityType, count := range types {
+		counts = append(counts, fmt.Sprintf("%d %s", count, activityType))
+	}
+	sort.Strings(counts)
+	repos := make([]string, 0, len(repositories))
+	for repo := range repositories {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	subject := prompt.Subject
+	if subject == "" {
+		subject = map[string]string{"summary": "the user", "team": "the team"}[prompt.Kind]
+	}
+	summary := fmt.Sprintf("Fake summary of %s: %d activities", subject, activities)
+	if len(repos) > 0 {
+		summary += fmt.Sprintf(" in %s", strings.Join(repos, ", "))
+	}
+	if len(counts) > 0 {
+		summary += fmt.Sprintf(" (%s)", strings.Join(counts, ", "))
+	}
+	return summary + "."
+}
@@ -0,0 +1,73 @@
+package ghsummary
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFakeSummarizerTemplate(t *testing.T) {
+	activity, err := GetUserActivity(context.Background(), "octo", 100, "fast", replayOctoFixtures())
+	if err != nil {
+		t.Fatalf("GetUserActivity failed: %v", err)
+	}
+	fake := &FakeSummarizer{}
+	summary, err := GenerateSummary(context.Background(), fake, activity, "she/her")
+	if err != nil {
+		t.Fatalf("GenerateSummary failed: %v", err)
+	}
+	want := "Fake summary of octo: 4 activities in acme/lib, octo/app (1 CreateEvent, 1 IssueCommentEvent, 1 PullRequestEvent, 1 PushEvent)."
+	if summary != want {
+		t.Errorf("unexpected summary:\n%s\nwant:\n%s", summary, want)
+	}
+
+	prompts := fake.Prompts()
+	if len(prompts) != 1 || prompts[0].Content != activity || !strings.Contains(prompts[0].SystemPrompt, "(she/her)") {
+		t.Errorf("expected the prompt to be recorded, got %+v", prompts)
+	}
+
+	commit, err := GenerateCommitSummary(context.Background(), fake, "Commit message: Add cache\nFile: cache.go\n")
+	if err != nil || commit != `Fake summary of the commit "Add cache".` {
+		t.Errorf("unexpected commit summary %q, %v", commit, err)
+	}
+}
+
+func TestFakeSummarizerFixturesAndPromptFiles(t *testing.T) {
+	fixtures, prompts := t.TempDir(), t.TempDir()
+	for name, content := range map[string]string{
+		"repository.txt":          "Any repository was busy.",
+		"repository-octo_app.txt": "octo/app shipped a cache.\n",
+	} {
+		if err := os.WriteFile(filepath.Join(fixtures, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GHSUMMARY_PROVIDER", "fake")
+	t.Setenv("GHSUMMARY_FAKE_FIXTURES", fixtures)
+	t.Setenv("GHSUMMARY_FAKE_PROMPTS", prompts)
+	summarizer, err := NewSummarizer("")
+	if err != nil {
+		t.Fatalf("NewSummarizer failed: %v", err)
+	}
+	if _, ok := summarizer.(*FakeSummarizer); !ok {
+		t.Fatalf("expected GHSUMMARY_PROVIDER to select the fake, got %T", summarizer)
+	}
+
+	for repo, want := range map[string]string{"octo/app": "octo/app shipped a cache.", "acme/lib": "Any repository was busy."} {
+		summary, err := GenerateRepositorySummary(context.Background(), summarizer, "Recent activities in "+repo, repo)
+		if err != nil || summary != want {
+			t.Errorf("expected %q for %s, got %q, %v", want, repo, summary, err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(prompts, "repository-*.txt"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("expected one prompt file per request, got %v, %v", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil || !strings.HasPrefix(string(data), "System:\nGenerate a concise summary") || !strings.Contains(string(data), "\n\nUser:\nRecent activities in ") {
+		t.Errorf("unexpected prompt file %q, %v", data, err)
+	}
+}
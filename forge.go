@@ -0,0 +1,83 @@
+package ghsummary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// forgeClient sends the requests of GitLab and Gitea sources. It shares the
+// transport of GitHub requests but none of its rate limiting or caching.
+var forgeClient = &http.Client{Transport: githubBaseTransport}
+
+// getForgeJSON fetches a JSON document from a GitLab or Gitea API with the
+// given authentication header and decodes it into out. A 404 is reported
+// with found set to false and no error.
+func getForgeJSON(ctx context.Context, url string, header string, token string, out any) (found bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set(header, token)
+	}
+	resp, err := forgeClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("request to %s failed: %s", req.URL.Redacted(), resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("error decoding JSON response: %w", err)
+	}
+	return true, nil
+}
+
+// forgeRepositoryName prefixes the repository path with the host of the
+// instance, e.g. "gitlab.example.com/group/project", so it cannot be
+// confused with a GitHub repository of the same name.
+func forgeRepositoryName(baseURL string, path string) string {
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Host != "" {
+		return parsed.Host + "/" + path
+	}
+	return path
+}
+
+// filterForgeRepository applies the name filters and the private repository
+// policy to a repository of a GitLab or Gitea instance. It returns the name
+// to report the activity under, which is PrivateRepositoryName for
+// anonymized repositories, and whether the activity is kept.
+func filterForgeRepository(repo string, private bool, filter RepoFilter) (string, bool) {
+	switch {
+	case !filter.allowsName(repo):
+		log.Printf("Skipping repository %s: excluded by filter", repo)
+		return "", false
+	case private && filter.Private == PrivateReposAnonymize:
+		return PrivateRepositoryName, true
+	case private && filter.Private != PrivateReposInclude:
+		log.Printf("Skipping private repository")
+		return "", false
+	}
+	return repo, true
+}
+
+// anonymizeForgeActivity replaces the content of activities in anonymized
+// private repositories and drops activities without content.
+func anonymizeForgeActivity(activity Activity) (Activity, bool) {
+	if activity.Repository == PrivateRepositoryName {
+		content, ok := privateActivityDescriptions[activity.Type]
+		activity.Content = content
+		return activity, ok
+	}
+	return activity, strings.TrimSpace(activity.Content) != ""
+}
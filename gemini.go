@@ -0,0 +1,186 @@
+package ghsummary
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// Default Gemini models used for the profile and commit summaries.
+const (
+	GeminiSummaryModel = "gemini-3.6-flash"
+	GeminiCommitModel  = "gemini-3.5-flash-lite"
+)
+
+// GeminiSummarizer generates summaries with the Gemini API.
+type GeminiSummarizer struct {
+	client      *genai.Client
+	Model       string
+	CommitModel string
+}
+
+// NewGeminiSummarizer creates a Gemini backend using the GEMINI_API_KEY
+// environment variable.
+func NewGeminiSummarizer() (*GeminiSummarizer, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("%w: GEMINI_API_KEY is not set", ErrProviderUnavailable)
+	}
+
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:  apiKey,
+		Backend: genai.BackendGeminiAPI,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: creating Gemini client: %v", ErrProviderUnavailable, err)
+	}
+	log.Printf("Gemini client created.")
+
+	return &GeminiSummarizer{
+		client:      client,
+		Model:       GeminiSummaryModel,
+		CommitModel: GeminiCommitModel,
+	}, nil
+}
+
+func (g *GeminiSummarizer) Summarize(ctx context.Context, activity string, pronouns string) (string, error) {
+	log.Printf("Generating summary...")
+	// Exponential backoff: 32s, 64s, 128s, 256s, 512s
+	summary, err := g.generateWithRetry(ctx, g.Model, fmt.Sprintf(SystemPromptSummary, pronouns), activity, 32*time.Second, 0)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Summary: %s", summary)
+	return summary, nil
+}
+
+func (g *GeminiSummarizer) SummarizeTeam(ctx context.Context, activity string, team string) (string, error) {
+	log.Printf("Generating team summary...")
+	summary, err := g.generateWithRetry(ctx, g.Model, fmt.Sprintf(SystemPromptTeamSummary, team), activity, 32*time.Second, 0)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Summary: %s", summary)
+	return summary, nil
+}
+
+func (g *GeminiSummarizer) SummarizeRepository(ctx context.Context, activity string, repo string) (string, error) {
+	log.Printf("Generating repository summary...")
+	summary, err := g.generateWithRetry(ctx, g.Model, fmt.Sprintf(SystemPromptRepositorySummary, repo), activity, 32*time.Second, 0)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Summary: %s", summary)
+	return summary, nil
+}
+
+func (g *GeminiSummarizer) SummarizeOrganization(ctx context.Context, activity string, org string) (string, error) {
+	log.Printf("Generating organization summary...")
+	summary, err := g.generateWithRetry(ctx, g.Model, fmt.Sprintf(SystemPromptOrganizationSummary, org), activity, 32*time.Second, 0)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Summary: %s", summary)
+	return summary, nil
+}
+
+func (g *GeminiSummarizer) SummarizeCommit(ctx context.Context, content string) (string, error) {
+	log.Printf("Generating commit summary...")
+	// Exponential backoff: 2s, 4s, 8s, 16s, 32s
+	summary, err := g.generateWithRetry(ctx, g.CommitModel, SystemPromptSummaryCommit, content, 2*time.Second, 0)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Commit Summary: %s", summary)
+	return summary, nil
+}
+
+// CommitModelName returns the model used for commit summaries.
+func (g *GeminiSummarizer) CommitModelName() string {
+	return g.CommitModel
+}
+
+func (g *GeminiSummarizer) generateWithRetry(ctx context.Context, model, systemPrompt, content string, baseBackoff time.Duration, attempt int) (string, error) {
+	const maxRetries = 5
+
+	log.Printf("Calling %s (attempt %d)", model, attempt+1)
+	result, err := g.client.Models.GenerateContent(ctx,
+		model,
+		genai.Text(content),
+		&genai.GenerateContentConfig{
+			SystemInstruction: &genai.Content{Parts: []*genai.Part{{Text: systemPrompt}}},
+		},
+	)
+	log.Printf("Generation completed.")
+
+	if err != nil {
+		// Check for server overload (503) or rate limit errors
+		errMsg := err.Error()
+		isOverloaded := strings.Contains(errMsg, "503") || strings.Contains(errMsg, "overloaded")
+		isRateLimit := strings.Contains(errMsg, "PerMinute")
+
+		if (isOverloaded || isRateLimit) && attempt < maxRetries {
+			waitDuration := time.Duration(1<<uint(attempt)) * baseBackoff
+			if isRateLimit {
+				// For rate limits, wait at least 1 minute
+				waitDuration = time.Minute
+			}
+			log.Printf("Error: %s. Retrying attempt %d/%d after %v", errMsg, attempt+1, maxRetries, waitDuration)
+			if err := sleepContext(ctx, waitDuration); err != nil {
+				return "", err
+			}
+			return g.generateWithRetry(ctx, model, systemPrompt, content, baseBackoff, attempt+1)
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", classifyGeminiError(model, err)
+	}
+
+	return geminiResponseText(model, result)
+}
+
+// classifyGeminiError wraps an API error with the matching sentinel error.
+func classifyGeminiError(model string, err error) error {
+	errMsg := err.Error()
+	if strings.Contains(errMsg, "429") || strings.Contains(errMsg, "RESOURCE_EXHAUSTED") || strings.Contains(errMsg, "PerMinute") {
+		return fmt.Errorf("%w: gemini %s: %v", ErrQuotaExceeded, model, err)
+	}
+	return fmt.Errorf("%w: gemini %s: %v", ErrProviderUnavailable, model, err)
+}
+
+// geminiResponseText extracts the text of the first candidate, reporting
+// safety blocks and empty answers as errors.
+func geminiResponseText(model string, result *genai.GenerateContentResponse) (string, error) {
+	if result == nil {
+		return "", fmt.Errorf("%w: gemini %s returned no response", ErrEmptyCompletion, model)
+	}
+	if feedback := result.PromptFeedback; feedback != nil && feedback.BlockReason != "" {
+		return "", fmt.Errorf("%w: gemini %s blocked the prompt: %s", ErrSafetyBlocked, model, feedback.BlockReason)
+	}
+	if len(result.Candidates) == 0 {
+		return "", fmt.Errorf("%w: gemini %s returned no candidates", ErrEmptyCompletion, model)
+	}
+
+	candidate := result.Candidates[0]
+	switch candidate.FinishReason {
+	case genai.FinishReasonSafety, genai.FinishReasonBlocklist, genai.FinishReasonProhibitedContent, genai.FinishReasonSPII:
+		return "", fmt.Errorf("%w: gemini %s stopped with %s", ErrSafetyBlocked, model, candidate.FinishReason)
+	}
+
+	summary := ""
+	if candidate.Content != nil {
+		for _, part := range candidate.Content.Parts {
+			summary += part.Text
+		}
+	}
+	if len(summary) == 0 {
+		return "", fmt.Errorf("%w: gemini %s", ErrEmptyCompletion, model)
+	}
+	return summary, nil
+}
@@ -0,0 +1,57 @@
+package ghsummary
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestGeminiResponseText(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  *genai.GenerateContentResponse
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "text parts are joined",
+			result: &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{Content: &genai.Content{Parts: []*genai.Part{{Text: "Hello "}, {Text: "world"}}}}}},
+			want:   "Hello world",
+		},
+		{
+			name:    "no candidates",
+			result:  &genai.GenerateContentResponse{},
+			wantErr: ErrEmptyCompletion,
+		},
+		{
+			name:    "blocked prompt",
+			result:  &genai.GenerateContentResponse{PromptFeedback: &genai.GenerateContentResponsePromptFeedback{BlockReason: genai.BlockedReasonSafety}},
+			wantErr: ErrSafetyBlocked,
+		},
+		{
+			name:    "safety finish reason",
+			result:  &genai.GenerateContentResponse{Candidates: []*genai.Candidate{{FinishReason: genai.FinishReasonSafety}}},
+			wantErr: ErrSafetyBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := geminiResponseText("model", tt.result)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewGeminiSummarizerWithoutAPIKey(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	if _, err := NewGeminiSummarizer(); !errors.Is(err, ErrProviderUnavailable) {
+		t.Errorf("expected ErrProviderUnavailable, got %v", err)
+	}
+}
@@ -1,30 +1,177 @@
 package ghsummary
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 )
 
-func GenerateSummarySVG(username string, max_events int, mode string) (string, bool) {
+// Options configures how activity is collected and summarized.
+type Options struct {
+	// Provider names the summarizer backend. Empty selects the
+	// GHSUMMARY_PROVIDER environment variable or DefaultProvider.
+	Provider string
+	// Summarizer is used as-is when set, taking precedence over Provider.
+	Summarizer Summarizer
+	// DisableFallback makes summaries fail when the summarizer does. By
+	// default a TemplateSummarizer answers instead.
+	DisableFallback bool
+	// TemplateDir holds templates overriding those of the TemplateSummarizer
+	// fallback. Empty uses the GHSUMMARY_TEMPLATES environment variable.
+	TemplateDir string
+	// Pronouns used for the user in the summary. Empty means "he/him".
+	Pronouns string
+	// AuthorEmails maps additional commit emails to GitHub logins, so commits
+	// made with an email not linked to the account are still attributed.
+	AuthorEmails map[string]string
+	// GitHubAPIURL is the REST API root, e.g. https://ghe.example.com/api/v3
+	// for GitHub Enterprise Server. Empty uses the GITHUB_API_URL environment
+	// variable, falling back to DefaultGitHubAPIURL.
+	GitHubAPIURL string
+	// RateLimiter tracks the GitHub API budget across requests. When nil,
+	// GetUserActivity creates one for the duration of the call.
+	RateLimiter *RateLimiter
+	// HTTPCache stores GitHub responses between runs and revalidates them
+	// with conditional requests. Nil disables caching.
+	HTTPCache *HTTPCache
+	// SummaryCache stores commit summaries made in strict mode between runs.
+	// Nil disables caching.
+	SummaryCache *SummaryCache
+	// Concurrency bounds the number of GitHub and LLM requests made in
+	// parallel. Zero or less uses DefaultConcurrency.
+	Concurrency int
+	// Since and Until limit the summary to events created in that period.
+	// Zero values leave the period open on that side.
+	Since time.Time
+	Until time.Time
+	// RepoFilter selects the repositories whose activity is summarized.
+	// Private repositories are excluded unless its Private policy says
+	// otherwise.
+	RepoFilter RepoFilter
+	// MaxRepositories is the number of most active repositories covered by
+	// an organization summary. Zero or less uses DefaultMaxRepositories.
+	MaxRepositories int
+	// Record saves every GitHub response as a fixture. Nil disables it.
+	Record *HTTPFixtures
+	// Replay serves GitHub responses from recorded fixtures instead of the
+	// network, bypassing the rate limiter and HTTPCache. Requests that were
+	// not recorded fail with ErrNoRecordedResponse.
+	Replay *HTTPFixtures
+}
+
+func (o Options) timeWindow() TimeWindow {
+	return TimeWindow{Since: o.Since, Until: o.Until}
+}
+
+func (o Options) concurrency() int {
+	if o.Concurrency <= 0 {
+		return DefaultConcurrency
+	}
+	return o.Concurrency
+}
+
+// githubTransport returns the round tripper used for GitHub requests.
+func (o Options) githubTransport() http.RoundTripper {
+	if o.Replay != nil {
+		return &replayTransport{fixtures: o.Replay, apiPaths: o.githubAPIPaths()}
+	}
+	transport := githubBaseTransport
+	if o.RateLimiter != nil {
+		transport = &rateLimitTransport{next: transport, limiter: o.RateLimiter}
+	}
+	if o.HTTPCache != nil {
+		// Outside the rate limiter, so immutable responses served from disk
+		// are not counted.
+		transport = &cacheTransport{next: transport, cache: o.HTTPCache}
+	}
+	if o.Record != nil {
+		// Outermost, so the fixtures hold exactly what the callers saw.
+		transport = &recordTransport{next: transport, fixtures: o.Record, apiPaths: o.githubAPIPaths()}
+	}
+	return transport
+}
+
+// githubAPIPaths returns the paths of the REST and GraphQL API roots, e.g.
+// /api/v3 and /api on GitHub Enterprise Server, and none on github.com.
+func (o Options) githubAPIPaths() []string {
+	var paths []string
+	for _, root := range []string{o.githubAPIURL(), strings.TrimSuffix(o.githubGraphQLURL(), "/graphql")} {
+		if parsed, err := url.Parse(root); err == nil && parsed.Path != "" && parsed.Path != "/" {
+			paths = append(paths, strings.TrimSuffix(parsed.Path, "/"))
+		}
+	}
+	return paths
+}
+
+func (o Options) githubAPIURL() string {
+	url := o.GitHubAPIURL
+	if url == "" {
+		url = os.Getenv("GITHUB_API_URL")
+	}
+	if url == "" {
+		url = DefaultGitHubAPIURL
+	}
+	return strings.TrimSuffix(url, "/")
+}
+
+// withSummarizer returns a copy of the options with Summarizer resolved from
+// Provider if it was not set explicitly, and wrapped with the template
+// fallback unless it is disabled.
+func (o Options) withSummarizer() (Options, error) {
+	summarizer := o.Summarizer
+	if summarizer == nil {
+		var err error
+		if summarizer, err = NewSummarizer(o.Provider); err != nil {
+			return o, err
+		}
+	}
+	if !o.DisableFallback {
+		var err error
+		if summarizer, err = WithTemplateFallback(summarizer, o.TemplateDir); err != nil {
+			return o, err
+		}
+	}
+	o.Summarizer = summarizer
+	return o, nil
+}
+
+// GenerateSummarySVG fetches the user's activity, summarizes it and renders
+// the summary as SVG. When the LLM fails, the summary is made from templates
+// unless opts.DisableFallback is set; LLM failures then wrap ErrQuotaExceeded,
+// ErrProviderUnavailable, ErrEmptyCompletion or ErrSafetyBlocked. Cancelling
+// ctx stops in-flight GitHub and LLM requests.
+func GenerateSummarySVG(ctx context.Context, username string, max_events int, mode string, opts Options) (string, error) {
+	opts, err := opts.withSummarizer()
+	if err != nil {
+		log.Printf("Error creating summarizer: %v", err)
+		return "", err
+	}
+
 	// Fetch GitHub activity
-	activity, err := GetUserActivity(username, max_events, mode)
+	activity, err := GetUserActivity(ctx, username, max_events, mode, opts)
 	if err != nil {
 		log.Printf("Error fetching GitHub activity: %v", err)
-		return "", false
+		return "", fmt.Errorf("fetching GitHub activity: %w", err)
 	}
 
 	// Generate summary using LLM
-	summary, err := GenerateSummary(activity)
+	summary, err := GenerateSummary(ctx, opts.Summarizer, activity, opts.Pronouns)
 	if err != nil {
 		log.Printf("Error generating summary: %v", err)
-		return "", false
+		return "", fmt.Errorf("generating summary: %w", err)
 	}
 
 	// Generate SVG content
-	svgContent, err := GenerateSVG(summary, "")
+	svgContent, err := GenerateSVG(ctx, summary, "", opts.timeWindow())
 	if err != nil {
 		log.Printf("Error generating SVG: %v", err)
-		return "", false
+		return "", fmt.Errorf("generating SVG: %w", err)
 	}
 
-	return svgContent, true
+	return svgContent, nil
 }
@@ -0,0 +1,245 @@
+package ghsummary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GiteaSource is the activity feed of a user of a Gitea or Forgejo
+// instance. Pushes, pull requests, issues and comments are mapped to the
+// activity types of the GitHub events feed. Repositories are named with the
+// host of the instance, e.g. "git.example.com/owner/repo", which is also
+// what RepoFilter patterns are matched against. The feed only lists commit
+// messages, so strict mode makes no difference.
+type GiteaSource struct {
+	// BaseURL is the root of the instance, e.g. https://git.example.com.
+	BaseURL string
+	// Username is the login of the user.
+	Username string
+	// Token is an access token with the read:user scope. Without one only
+	// public activity is visible.
+	Token string
+}
+
+// giteaActivity is an entry of the Gitea activity feed.
+type giteaActivity struct {
+	ID      int64  `json:"id"`
+	OpType  string `json:"op_type"`
+	ActUser struct {
+		Login string `json:"login"`
+	} `json:"act_user"`
+	Repo struct {
+		FullName    string `json:"full_name"`
+		Description string `json:"description"`
+		Private     bool   `json:"private"`
+	} `json:"repo"`
+	RefName   string `json:"ref_name"`
+	IsPrivate bool   `json:"is_private"`
+	// Content depends on OpType: the pushed commits as JSON for pushes and
+	// "index|text" for pull requests, issues and comments.
+	Content string    `json:"content"`
+	Created time.Time `json:"created"`
+}
+
+// giteaPushCommits is the content of a commit_repo activity. Commits are
+// listed newest first and may be truncated; Len is the number pushed.
+type giteaPushCommits struct {
+	Commits []struct {
+		Sha1           string    `json:"Sha1"`
+		Message        string    `json:"Message"`
+		AuthorEmail    string    `json:"AuthorEmail"`
+		AuthorName     string    `json:"AuthorName"`
+		CommitterEmail string    `json:"CommitterEmail"`
+		CommitterName  string    `json:"CommitterName"`
+		Timestamp      time.Time `json:"Timestamp"`
+	} `json:"Commits"`
+	Len int `json:"Len"`
+}
+
+func (s GiteaSource) Name() string {
+	return "Gitea user " + s.Username + " on " + s.baseURL()
+}
+
+func (s GiteaSource) baseURL() string {
+	return strings.TrimSuffix(s.BaseURL, "/")
+}
+
+func (s GiteaSource) get(ctx context.Context, endpoint string, out any) (bool, error) {
+	token := ""
+	if s.Token != "" {
+		token = "token " + s.Token
+	}
+	return getForgeJSON(ctx, s.baseURL()+"/api/v1/"+endpoint, "Authorization", token, out)
+}
+
+func (s GiteaSource) Activities(ctx context.Context, maxEvents int, mode string, opts Options) ([]Activity, error) {
+	if s.BaseURL == "" {
+		return nil, fmt.Errorf("no Gitea URL for user %s", s.Username)
+	}
+	window := opts.timeWindow()
+	// Repository names by full name; an empty name means the repository is skipped.
+	repositories := make(map[string]string)
+	perPage := min(max(maxEvents, 1), 50)
+	const maxPages = 10
+	var activities []Activity
+	for page := 1; page <= maxPages && len(activities) < maxEvents; page++ {
+		log.Printf("Fetching page %d of the Gitea activity feed for %s", page, s.Username)
+		var feed []giteaActivity
+		endpoint := fmt.Sprintf("users/%s/activities/feeds?only-performed-by=true&limit=%d&page=%d", url.PathEscape(s.Username), perPage, page)
+		found, err := s.get(ctx, endpoint, &feed)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("Gitea user %s not found", s.Username)
+		}
+		reachedSince := false
+		for _, entry := range feed {
+			if len(activities) >= maxEvents {
+				break
+			}
+			if !window.Since.IsZero() && entry.Created.Before(window.Since) {
+				reachedSince = true
+			}
+			if !window.Contains(entry.Created) || entry.Repo.FullName == "" {
+				continue
+			}
+			repo, known := repositories[entry.Repo.FullName]
+			if !known {
+				repo, _ = filterForgeRepository(forgeRepositoryName(s.baseURL(), entry.Repo.FullName), entry.Repo.Private || entry.IsPrivate, opts.RepoFilter)
+				repositories[entry.Repo.FullName] = repo
+			}
+			if repo == "" {
+				continue
+			}
+			activity, ok := s.activity(entry, repo, opts)
+			if ok {
+				activity, ok = anonymizeForgeActivity(activity)
+			}
+			if !ok {
+				log.Printf("[gitea %d] Skipping %s activity", entry.ID, entry.OpType)
+				continue
+			}
+			activities = append(activities, activity)
+		}
+		if reachedSince || len(feed) < perPage {
+			break
+		}
+	}
+	return activities, nil
+}
+
+// activity maps a feed entry to the activity type of the equivalent GitHub
+// event.
+func (s GiteaSource) activity(entry giteaActivity, repo string, opts Options) (Activity, bool) {
+	activity := Activity{Repository: repo, Actor: entry.ActUser.Login, CreatedAt: entry.Created}
+	index, text, _ := strings.Cut(entry.Content, "|")
+	ref := strings.TrimPrefix(strings.TrimPrefix(entry.RefName, "refs/heads/"), "refs/tags/")
+	switch entry.OpType {
+	case "commit_repo", "mirror_sync_push":
+		activity.Type = "PushEvent"
+		activity.Content = s.pushMessages(entry, opts)
+	case "create_pull_request", "merge_pull_request", "close_pull_request", "reopen_pull_request":
+		action := map[string]string{
+			"create_pull_request": "Pull request opened",
+			"merge_pull_request":  "Pull request merged",
+			"close_pull_request":  "Pull request closed without merging",
+			"reopen_pull_request": "Pull request reopened",
+		}[entry.OpType]
+		activity.Type = "PullRequestEvent"
+		activity.Content = fmt.Sprintf("%s: #%s %s\n", action, index, text)
+	case "approve_pull_request", "reject_pull_request":
+		action := "Approved pull request"
+		if entry.OpType == "reject_pull_request" {
+			action = "Requested changes on pull request"
+		}
+		activity.Type = "PullRequestReviewEvent"
+		activity.Content = fmt.Sprintf("%s: #%s\n", action, index)
+		if review := excerpt(text, maxBodyExcerpt); review != "" {
+			activity.Content += fmt.Sprintf("Review: %s\n", review)
+		}
+	case "create_issue", "close_issue", "reopen_issue":
+		action := map[string]string{
+			"create_issue": "Issue opened",
+			"close_issue":  "Issue closed",
+			"reopen_issue": "Issue reopened",
+		}[entry.OpType]
+		activity.Type = "IssuesEvent"
+		activity.Content = fmt.Sprintf("%s: #%s %s\n", action, index, text)
+	case "comment_issue", "comment_pull":
+		thread := "issue"
+		if entry.OpType == "comment_pull" {
+			thread = "pull request"
+		}
+		activity.Type = "IssueCommentEvent"
+		activity.Content = fmt.Sprintf("Commented on %s #%s\nComment: %s\n", thread, index, excerpt(text, maxBodyExcerpt))
+	case "publish_release":
+		activity.Type = "ReleaseEvent"
+		activity.Content = fmt.Sprintf("Published release %s\n", ref)
+	case "push_tag":
+		activity.Type = "CreateEvent"
+		activity.Content = fmt.Sprintf("Created tag %s\n", ref)
+	case "create_repo":
+		activity.Type = "CreateEvent"
+		activity.Content = "Created the repository\n"
+	case "delete_tag", "delete_branch":
+		activity.Type = "DeleteEvent"
+		activity.Content = fmt.Sprintf("Deleted %s %s\n", strings.TrimPrefix(entry.OpType, "delete_"), ref)
+	default:
+		return Activity{}, false
+	}
+	return activity, true
+}
+
+// pushMessages lists the pushed commits oldest first like the commits of a
+// GitHub push, crediting them the same way.
+func (s GiteaSource) pushMessages(entry giteaActivity, opts Options) string {
+	var pushed giteaPushCommits
+	if err := json.Unmarshal([]byte(entry.Content), &pushed); err != nil {
+		log.Printf("[gitea %d] Error decoding pushed commits: %v", entry.ID, err)
+		return ""
+	}
+	commits := make([]RepoCommit, 0, len(pushed.Commits))
+	for i := len(pushed.Commits) - 1; i >= 0; i-- {
+		pushedCommit := pushed.Commits[i]
+		var commit RepoCommit
+		commit.SHA = pushedCommit.Sha1
+		commit.Commit.Message = strings.TrimRight(pushedCommit.Message, "\n")
+		commit.Commit.Author = CommitSignature{Name: pushedCommit.AuthorName, Email: pushedCommit.AuthorEmail, Date: pushedCommit.Timestamp}
+		commit.Commit.Committer = CommitSignature{Name: pushedCommit.CommitterName, Email: pushedCommit.CommitterEmail, Date: pushedCommit.Timestamp}
+		commits = append(commits, commit)
+	}
+	messages := newPushCommits(commits, s.Username, opts.AuthorEmails).messages(fmt.Sprintf("gitea %d", entry.ID))
+	if more := pushed.Len - len(pushed.Commits); more > 0 && messages != "" {
+		messages += fmt.Sprintf("The push contained %s.\n", plural(more, "more commit"))
+	}
+	return messages
+}
+
+// DescribeRepositories uses the descriptions of the Gitea repositories.
+func (s GiteaSource) DescribeRepositories(ctx context.Context, repositories []string, opts Options) (map[string]string, error) {
+	prefix := forgeRepositoryName(s.baseURL(), "")
+	descriptions := make(map[string]string)
+	for _, repo := range repositories {
+		fullName, ok := strings.CutPrefix(repo, prefix)
+		if !ok {
+			continue
+		}
+		var repository struct {
+			Description string `json:"description"`
+		}
+		if found, err := s.get(ctx, "repos/"+fullName, &repository); err != nil || !found {
+			log.Printf("Error fetching Gitea repository %s: %v", fullName, err)
+			continue
+		}
+		if repository.Description != "" {
+			descriptions[repo] = repository.Description
+		}
+	}
+	return descriptions, nil
+}
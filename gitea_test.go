@@ -0,0 +1,86 @@
+package ghsummary
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const giteaFeedFixture = `[
+	{"id": 7, "op_type": "close_issue", "act_user": {"login": "octo"}, "repo": {"full_name": "infra/deploy"},
+	 "content": "3|Flaky rollout", "created": "2026-10-15T10:00:00Z"},
+	{"id": 6, "op_type": "comment_pull", "act_user": {"login": "octo"}, "repo": {"full_name": "infra/deploy"},
+	 "content": "4|Looks good, merging after CI.", "created": "2026-10-14T10:00:00Z"},
+	{"id": 5, "op_type": "merge_pull_request", "act_user": {"login": "octo"}, "repo": {"full_name": "infra/deploy"},
+	 "content": "4|Deploy with blue-green", "created": "2026-10-13T10:00:00Z"},
+	{"id": 4, "op_type": "commit_repo", "act_user": {"login": "octo"}, "repo": {"full_name": "infra/deploy"}, "ref_name": "refs/heads/main",
+	 "content": "{\"Commits\": [{\"Sha1\": \"b2\", \"Message\": \"Switch traffic\\n\", \"AuthorEmail\": \"octo@example.com\"}, {\"Sha1\": \"b1\", \"Message\": \"Add green pool\\n\", \"AuthorEmail\": \"octo@example.com\"}], \"Len\": 3}",
+	 "created": "2026-10-12T10:00:00Z"},
+	{"id": 3, "op_type": "star_repo", "act_user": {"login": "octo"}, "repo": {"full_name": "infra/deploy"},
+	 "created": "2026-10-12T09:00:00Z"},
+	{"id": 2, "op_type": "create_issue", "act_user": {"login": "octo"}, "repo": {"full_name": "infra/secrets", "private": true},
+	 "content": "9|Rotate tokens", "created": "2026-10-11T10:00:00Z"},
+	{"id": 1, "op_type": "create_issue", "act_user": {"login": "octo"}, "repo": {"full_name": "infra/deploy"},
+	 "content": "1|Old issue", "created": "2026-10-01T10:00:00Z"}
+]`
+
+func TestGiteaSourceActivities(t *testing.T) {
+	pages := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			t.Errorf("expected the token in the Authorization header, got %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/api/v1/users/octo/activities/feeds":
+			pages++
+			if r.URL.Query().Get("only-performed-by") != "true" {
+				t.Errorf("expected only the user's own activities to be requested: %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, giteaFeedFixture)
+		case "/api/v1/repos/infra/deploy":
+			fmt.Fprint(w, `{"full_name": "infra/deploy", "description": "Deployment scripts."}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	source := GiteaSource{BaseURL: server.URL, Username: "octo", Token: "secret"}
+	opts := Options{
+		Since:      time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC),
+		RepoFilter: RepoFilter{Private: PrivateReposAnonymize},
+	}
+	activities, err := source.Activities(context.Background(), 7, "fast", opts)
+	if err != nil {
+		t.Fatalf("Activities failed: %v", err)
+	}
+
+	want := []Activity{
+		{Type: "IssuesEvent", Repository: host + "/infra/deploy", Content: "Issue closed: #3 Flaky rollout\n"},
+		{Type: "IssueCommentEvent", Repository: host + "/infra/deploy", Content: "Commented on pull request #4\nComment: Looks good, merging after CI.\n"},
+		{Type: "PullRequestEvent", Repository: host + "/infra/deploy", Content: "Pull request merged: #4 Deploy with blue-green\n"},
+		{Type: "PushEvent", Repository: host + "/infra/deploy", Content: "Add green pool\nSwitch traffic\nThe push contained 1 more commit.\n"},
+		{Type: "IssuesEvent", Repository: PrivateRepositoryName, Content: "Worked on an issue"},
+	}
+	if len(activities) != len(want) {
+		t.Fatalf("expected %d activities, got %+v", len(want), activities)
+	}
+	for i, activity := range activities {
+		if activity.Type != want[i].Type || activity.Repository != want[i].Repository || activity.Content != want[i].Content || activity.Actor != "octo" {
+			t.Errorf("activity %d: expected %+v, got %+v", i, want[i], activity)
+		}
+	}
+	if pages != 1 {
+		t.Errorf("expected paging to stop at the start of the period, got %d pages", pages)
+	}
+
+	descriptions, err := source.DescribeRepositories(context.Background(), []string{host + "/infra/deploy"}, opts)
+	if err != nil || descriptions[host+"/infra/deploy"] != "Deployment scripts." {
+		t.Errorf("unexpected descriptions: %v, %v", descriptions, err)
+	}
+}
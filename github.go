@@ -1,25 +1,46 @@
 package ghsummary
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 type Activity struct {
 	Type       string
 	Repository string
 	Content    string
+	// Actor is the login of the user who performed the activity.
+	Actor string
+	// CreatedAt is when the activity happened, used to merge the activities
+	// of several sources. It is zero when unknown.
+	CreatedAt time.Time
 }
 
-// makeGitHubRequest creates an HTTP GET request with GitHub token authentication if available
-func makeGitHubRequest(url string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
+// DefaultGitHubAPIURL is the REST API root of github.com.
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+// githubBaseTransport sends all GitHub requests. The response header timeout
+// bounds a single request; callers bound the whole run through the context.
+var githubBaseTransport http.RoundTripper = func() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	return transport
+}()
+
+// newGitHubRequest creates an HTTP GET request with GitHub token authentication if available
+func newGitHubRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -29,54 +50,325 @@ func makeGitHubRequest(url string) (*http.Response, error) {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 		log.Printf("Using GitHub token for authentication")
 	}
+	return req, nil
+}
 
-	client := &http.Client{}
+// makeGitHubRequest sends a GET request through the transport configured in opts
+func makeGitHubRequest(ctx context.Context, url string, opts Options) (*http.Response, error) {
+	req, err := newGitHubRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: opts.githubTransport()}
 	return client.Do(req)
 }
 
-func GetRepositoryName(event map[string]interface{}) (string, error) {
-	repo, ok := event["repo"].(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("error parsing repo data")
+// isStrictMode reports whether commits should be summarized individually.
+func isStrictMode(mode string) bool {
+	return strings.EqualFold(mode, "strict")
+}
+
+// CommitSignature is the git-level author or committer of a commit.
+type CommitSignature struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+// CommitFile is a file changed by a commit. Patch is empty for binary or
+// very large diffs.
+type CommitFile struct {
+	Filename  string `json:"filename"`
+	Status    string `json:"status"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Changes   int    `json:"changes"`
+	Patch     string `json:"patch"`
+}
+
+// RepoCommit is a commit as returned by the compare and commit APIs. Author
+// and Committer are the linked GitHub accounts and are nil when the commit
+// email is not associated with any account. Files is only populated by the
+// single commit API.
+type RepoCommit struct {
+	SHA     string `json:"sha"`
+	URL     string `json:"url"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
+		Message   string          `json:"message"`
+		Author    CommitSignature `json:"author"`
+		Committer CommitSignature `json:"committer"`
+	} `json:"commit"`
+	Author    *User `json:"author"`
+	Committer *User `json:"committer"`
+	Parents   []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+	Files []CommitFile `json:"files,omitempty"`
+}
+
+type compareResponse struct {
+	Commits []RepoCommit `json:"commits"`
+}
+
+// GetIssueCommentEventContent describes the user's comment together with the
+// issue or pull request it was posted on.
+func GetIssueCommentEventContent(payload *IssueCommentEventPayload) (string, error) {
+	var action string
+	switch payload.Action {
+	case "created":
+		action = "Commented on"
+	case "edited":
+		action = "Edited a comment on"
+	case "deleted":
+		return "", fmt.Errorf("comment was deleted")
+	default:
+		return "", fmt.Errorf("unsupported action: %s", payload.Action)
 	}
-	name, ok := repo["name"].(string)
-	if !ok {
-		return "", fmt.Errorf("error parsing repo name")
+
+	issue := payload.Issue
+	thread := "issue"
+	state := issue.State
+	if issue.PullRequest != nil {
+		thread = "pull request"
+		if issue.PullRequest.MergedAt != nil {
+			state = "merged"
+		}
 	}
-	return name, nil
+	if state == "" {
+		state = "unknown"
+	}
+
+	content := fmt.Sprintf("%s %s #%d: %s (%s)\n", action, thread, issue.Number, issue.Title, state)
+	if issue.User.Login != "" {
+		content += fmt.Sprintf("Opened by: %s\n", issue.User.Login)
+	}
+	content += fmt.Sprintf("Comment: %s\n", excerpt(payload.Comment.Body, maxBodyExcerpt))
+	return content, nil
+}
+
+// firstLine returns the subject line of a commit message.
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return strings.TrimSpace(line)
+}
+
+// indentMessage indents the continuation lines of a commit message, so the
+// commits listed in a push can be told apart.
+func indentMessage(message string) string {
+	return strings.ReplaceAll(strings.TrimRight(message, "\n"), "\n", "\n  ")
+}
+
+// Limits for how much of a body goes into the prompt. Release notes get more
+// room as they usually describe the whole release.
+const (
+	maxBodyExcerpt         = 500
+	maxReleaseNotesExcerpt = 1000
+)
+
+// excerpt shortens text to at most limit bytes without splitting a UTF-8
+// character, marking the cut with an ellipsis.
+func excerpt(text string, limit int) string {
+	text = strings.TrimSpace(text)
+	if len(text) <= limit {
+		return text
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return strings.TrimSpace(text[:cut]) + "…"
 }
 
-func GetIssueCommentEventContent(payload map[string]interface{}) (string, error) {
-	action, ok := payload["action"].(string)
-	if !ok {
-		return "", fmt.Errorf("error parsing action data")
+// GetPullRequest fetches the full pull request from its API URL. The Events
+// API may omit the title, body and diff stats from PullRequestEvent payloads.
+func GetPullRequest(ctx context.Context, url string, opts Options) (*PullRequest, error) {
+	resp, err := makeGitHubRequest(ctx, url, opts)
+	if err != nil {
+		return nil, err
 	}
-	switch action {
-	case "created":
-		issue, ok := payload["issue"].(map[string]interface{})
-		if !ok {
-			return "", fmt.Errorf("error parsing issue data")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch pull request: %s", resp.Status)
+	}
+
+	var pr PullRequest
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return nil, fmt.Errorf("error decoding JSON response: %w", err)
+	}
+	return &pr, nil
+}
+
+// completePullRequest fills in details missing from a trimmed event payload.
+func completePullRequest(ctx context.Context, pr PullRequest, opts Options) PullRequest {
+	if pr.Title != "" || pr.URL == "" {
+		return pr
+	}
+	full, err := GetPullRequest(ctx, pr.URL, opts)
+	if err != nil {
+		log.Printf("Error fetching pull request %s: %v", pr.URL, err)
+		return pr
+	}
+	return *full
+}
+
+func pullRequestHeader(pr PullRequest) string {
+	return fmt.Sprintf("#%d %s", pr.Number, pr.Title)
+}
+
+// pullRequestActions lists the PullRequestEvent actions that become activities.
+var pullRequestActions = map[string]bool{"opened": true, "closed": true, "reopened": true}
+
+func GetPullRequestEventContent(payload *PullRequestEventPayload) (string, error) {
+	pr := payload.PullRequest
+	if pr.Number == 0 {
+		pr.Number = payload.Number
+	}
+	var action string
+	switch payload.Action {
+	case "opened":
+		action = "Pull request opened"
+		if pr.Draft {
+			action = "Draft pull request opened"
 		}
-		title, ok := issue["title"].(string)
-		if !ok {
-			return "", fmt.Errorf("error parsing issue title")
+	case "closed":
+		action = "Pull request closed without merging"
+		if pr.Merged || pr.MergedAt != nil {
+			action = "Pull request merged"
 		}
-		body, ok := issue["body"].(string)
-		if !ok {
-			log.Printf("No body, using empty string")
-			body = ""
+	case "reopened":
+		action = "Pull request reopened"
+	default:
+		return "", fmt.Errorf("unsupported action: %s", payload.Action)
+	}
+
+	content := fmt.Sprintf("%s: %s\n", action, pullRequestHeader(pr))
+	if pr.ChangedFiles > 0 || pr.Additions > 0 || pr.Deletions > 0 {
+		content += fmt.Sprintf("Changes: +%d -%d in %d files, %d commits\n", pr.Additions, pr.Deletions, pr.ChangedFiles, pr.Commits)
+	}
+	if body := excerpt(pr.Body, maxBodyExcerpt); body != "" {
+		content += body + "\n"
+	}
+	return content, nil
+}
+
+func GetPullRequestReviewEventContent(payload *PullRequestReviewEventPayload) (string, error) {
+	switch payload.Action {
+	case "created", "submitted":
+	default:
+		return "", fmt.Errorf("unsupported action: %s", payload.Action)
+	}
+
+	var action string
+	switch strings.ToLower(payload.Review.State) {
+	case "approved":
+		action = "Approved pull request"
+	case "changes_requested":
+		action = "Requested changes on pull request"
+	case "commented":
+		action = "Reviewed pull request"
+	default:
+		return "", fmt.Errorf("unsupported review state: %s", payload.Review.State)
+	}
+
+	content := fmt.Sprintf("%s: %s\n", action, pullRequestHeader(payload.PullRequest))
+	if body := excerpt(payload.Review.Body, maxBodyExcerpt); body != "" {
+		content += fmt.Sprintf("Review: %s\n", body)
+	}
+	return content, nil
+}
+
+func GetPullRequestReviewCommentEventContent(payload *PullRequestReviewCommentEventPayload) (string, error) {
+	if payload.Action != "created" {
+		return "", fmt.Errorf("unsupported action: %s", payload.Action)
+	}
+
+	content := fmt.Sprintf("Review comment on pull request: %s\n", pullRequestHeader(payload.PullRequest))
+	if payload.Comment.Path != "" {
+		content += fmt.Sprintf("File: %s\n", payload.Comment.Path)
+	}
+	content += fmt.Sprintf("Comment: %s\n", excerpt(payload.Comment.Body, maxBodyExcerpt))
+	return content, nil
+}
+
+func GetIssuesEventContent(payload *IssuesEventPayload) (string, error) {
+	issue := payload.Issue
+	var action string
+	switch payload.Action {
+	case "opened":
+		action = "Issue opened"
+	case "closed":
+		action = "Issue closed"
+		if issue.StateReason == "not_planned" {
+			action = "Issue closed as not planned"
+		}
+	case "reopened":
+		action = "Issue reopened"
+	default:
+		return "", fmt.Errorf("unsupported action: %s", payload.Action)
+	}
+
+	content := fmt.Sprintf("%s: #%d %s\n", action, issue.Number, issue.Title)
+	if body := excerpt(issue.Body, maxBodyExcerpt); body != "" && payload.Action == "opened" {
+		content += body + "\n"
+	}
+	return content, nil
+}
+
+func GetReleaseEventContent(payload *ReleaseEventPayload) (string, error) {
+	if payload.Action != "published" {
+		return "", fmt.Errorf("unsupported action: %s", payload.Action)
+	}
+	release := payload.Release
+
+	kind := "Release"
+	if release.Prerelease {
+		kind = "Pre-release"
+	}
+	name := release.Name
+	if name == "" {
+		name = release.TagName
+	}
+	content := fmt.Sprintf("%s published: %s (tag %s)\n", kind, name, release.TagName)
+	if notes := excerpt(release.Body, maxReleaseNotesExcerpt); notes != "" {
+		content += fmt.Sprintf("Release notes:\n%s\n", notes)
+	}
+	return content, nil
+}
+
+func GetCreateEventContent(payload *CreateEventPayload) (string, error) {
+	switch payload.RefType {
+	case "repository":
+		content := "Repository created\n"
+		if payload.Description != "" {
+			content += fmt.Sprintf("Description: %s\n", payload.Description)
 		}
-		return fmt.Sprintf("Issue created: %s\n%s", title, body), nil
+		return content, nil
+	case "branch":
+		return fmt.Sprintf("Branch created: %s\n", payload.Ref), nil
+	case "tag":
+		return fmt.Sprintf("Tag created: %s\n", payload.Ref), nil
+	}
+	return "", fmt.Errorf("unsupported ref type: %s", payload.RefType)
+}
+
+func GetDeleteEventContent(payload *DeleteEventPayload) (string, error) {
+	switch payload.RefType {
+	case "branch":
+		return fmt.Sprintf("Branch deleted: %s\n", payload.Ref), nil
+	case "tag":
+		return fmt.Sprintf("Tag deleted: %s\n", payload.Ref), nil
 	}
-	return "", fmt.Errorf("unsupported action: %s", action)
+	return "", fmt.Errorf("unsupported ref type: %s", payload.RefType)
 }
 
-func GetPushEventCommits(repo string, before string, after string) ([]interface{}, error) {
+func GetPushEventCommits(ctx context.Context, repo string, before string, after string, opts Options) ([]RepoCommit, error) {
 	// Use GitHub's compare API to fetch commits between two SHAs
-	url := fmt.Sprintf("https://api.github.com/repos/%s/compare/%s...%s", repo, before, after)
+	url := fmt.Sprintf("%s/repos/%s/compare/%s...%s", opts.githubAPIURL(), repo, before, after)
 	log.Printf("Fetching commits from compare API: %s", url)
 
-	resp, err := makeGitHubRequest(url)
+	resp, err := makeGitHubRequest(ctx, url, opts)
 	if err != nil {
 		log.Printf("Error making HTTP request: %v", err)
 		return nil, err
@@ -88,31 +380,23 @@ func GetPushEventCommits(repo string, before string, after string) ([]interface{
 		return nil, fmt.Errorf("failed to fetch commits: %s", resp.Status)
 	}
 
-	var compareData map[string]interface{}
+	var compareData compareResponse
 	if err := json.NewDecoder(resp.Body).Decode(&compareData); err != nil {
 		log.Printf("Error decoding JSON response: %v", err)
 		return nil, err
 	}
 
-	commits, ok := compareData["commits"].([]interface{})
-	if !ok {
-		log.Printf("Error parsing commits data from compare API")
-		return nil, fmt.Errorf("error parsing commits data")
-	}
-
-	return commits, nil
+	return compareData.Commits, nil
 }
 
-func GetCommitSummary(commit map[string]interface{}) (string, bool) {
-	message, ok := commit["message"].(string)
-	if !ok {
+func GetCommitSummary(ctx context.Context, repo string, commit RepoCommit, opts Options) (string, bool) {
+	if commit.URL == "" {
 		return "", false
 	}
-	url, ok := commit["url"].(string)
-	if !ok {
-		return "", false
+	if summary, ok := cachedCommitSummary(repo, commit.SHA, opts); ok {
+		return summary, true
 	}
-	resp, err := makeGitHubRequest(url)
+	resp, err := makeGitHubRequest(ctx, commit.URL, opts)
 	if err != nil {
 		log.Printf("Error making HTTP request: %v", err)
 		return "", false
@@ -122,38 +406,44 @@ func GetCommitSummary(commit map[string]interface{}) (string, bool) {
 		log.Printf("Non-OK HTTP status: %s", resp.Status)
 		return "", false
 	}
-	var commitData map[string]interface{}
+	var commitData RepoCommit
 	if err := json.NewDecoder(resp.Body).Decode(&commitData); err != nil {
 		log.Printf("Error decoding JSON response: %v", err)
 		return "", false
 	}
-
-	commitContentToSummarize := fmt.Sprintf("Commit message: %s\n", message)
-	if files, ok := commitData["files"].([]interface{}); ok {
-		for _, file := range files {
-			fileData, ok := file.(map[string]interface{})
-			if !ok {
-				log.Printf("Error parsing file data")
-				continue
-			}
-			filename, ok := fileData["filename"].(string)
-			if !ok {
-				log.Printf("Error parsing filename")
-				continue
-			}
-			patch, ok := fileData["patch"].(string)
-			if !ok {
-				log.Printf("Error parsing patch data for url: %s and file: %s", url, filename)
-				continue
-			}
-			commitContentToSummarize += fmt.Sprintf("File: %s\nPatch:\n%s\n", filename, patch)
-		}
-	} else {
+	if commitData.Files == nil {
 		log.Printf("Error parsing files data")
 		return "", false
 	}
+	return summarizeCommitFiles(ctx, repo, commit, commitData.Files, opts)
+}
+
+// cachedCommitSummary returns the summary of the commit stored in
+// opts.SummaryCache, if any.
+func cachedCommitSummary(repo string, sha string, opts Options) (string, bool) {
+	if opts.SummaryCache == nil || sha == "" {
+		return "", false
+	}
+	summary, ok := opts.SummaryCache.Get(commitSummaryKey(repo, sha, opts.Summarizer))
+	if ok {
+		log.Printf("Using cached summary for commit %s", sha)
+	}
+	return summary, ok
+}
+
+// summarizeCommitFiles summarizes a commit from its message and the patches
+// of the changed files, and stores the summary in opts.SummaryCache.
+func summarizeCommitFiles(ctx context.Context, repo string, commit RepoCommit, files []CommitFile, opts Options) (string, bool) {
+	commitContentToSummarize := fmt.Sprintf("Commit message: %s\n", commit.Commit.Message)
+	for _, file := range files {
+		if file.Patch == "" {
+			log.Printf("No patch data for commit: %s and file: %s", commit.SHA, file.Filename)
+			continue
+		}
+		commitContentToSummarize += fmt.Sprintf("File: %s\nPatch:\n%s\n", file.Filename, file.Patch)
+	}
 
-	commit_summary, err := GenerateCommitSummary(commitContentToSummarize, 0)
+	commit_summary, err := GenerateCommitSummary(ctx, opts.Summarizer, commitContentToSummarize)
 	if err != nil {
 		log.Printf("Error generating commit summary: %v", err)
 		return "", false
@@ -162,20 +452,97 @@ func GetCommitSummary(commit map[string]interface{}) (string, bool) {
 		log.Printf("No commit summary generated")
 		return "", false
 	}
+	if opts.SummaryCache != nil && commit.SHA != "" {
+		if err := opts.SummaryCache.Put(commitSummaryKey(repo, commit.SHA, opts.Summarizer), commit_summary); err != nil {
+			log.Printf("Error caching commit summary: %v", err)
+		}
+	}
 
 	return commit_summary, true
 }
 
-func GetEvents(username string, perPageEvents int, page int) ([]map[string]interface{}, error) {
+// GetRepository fetches the repository metadata, including its topics.
+func GetRepository(ctx context.Context, repo string, opts Options) (*Repository, error) {
+	url := fmt.Sprintf("%s/repos/%s", opts.githubAPIURL(), repo)
+	resp, err := makeGitHubRequest(ctx, url, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch repository: %s", resp.Status)
+	}
+	var repository Repository
+	if err := json.NewDecoder(resp.Body).Decode(&repository); err != nil {
+		return nil, fmt.Errorf("error decoding JSON response: %w", err)
+	}
+	return &repository, nil
+}
+
+// RepositoryContent is a file as returned by the repository contents API.
+type RepositoryContent struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	SHA      string `json:"sha"`
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+}
+
+// GetRepositoryReadme returns the decoded README.md of the repository, or an
+// empty string when the repository has none.
+func GetRepositoryReadme(ctx context.Context, repo string, opts Options) (string, error) {
+	readmeURL := fmt.Sprintf("%s/repos/%s/contents/README.md", opts.githubAPIURL(), repo)
+	resp, err := makeGitHubRequest(ctx, readmeURL, opts)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch README.md: %s", resp.Status)
+	}
+
+	var readmeContent RepositoryContent
+	if err := json.NewDecoder(resp.Body).Decode(&readmeContent); err != nil {
+		return "", fmt.Errorf("error decoding JSON response: %w", err)
+	}
+
+	// Decode the base64 content
+	decodedContent, err := base64.StdEncoding.DecodeString(readmeContent.Content)
+	if err != nil {
+		return "", fmt.Errorf("error decoding base64 content: %w", err)
+	}
+	return string(decodedContent), nil
+}
+
+// GetEvents fetches one page of the user's public events. Events that could
+// not be fully decoded are reported in the returned diagnostics.
+func GetEvents(ctx context.Context, username string, perPageEvents int, page int, opts Options) ([]Event, []EventDiagnostic, error) {
 	log.Printf("Fetching %d events for %s user. Page %d", perPageEvents, username, page)
-	url := fmt.Sprintf("https://api.github.com/users/%s/events?per_page=%d&page=%d", username, perPageEvents, page)
+	return getEventsPage(ctx, fmt.Sprintf("users/%s/events", username), perPageEvents, page, opts)
+}
+
+// GetRepositoryEvents fetches one page of the events of a repository, given
+// as owner/name.
+func GetRepositoryEvents(ctx context.Context, repo string, perPageEvents int, page int, opts Options) ([]Event, []EventDiagnostic, error) {
+	log.Printf("Fetching %d events for %s repository. Page %d", perPageEvents, repo, page)
+	return getEventsPage(ctx, fmt.Sprintf("repos/%s/events", repo), perPageEvents, page, opts)
+}
+
+// getEventsPage fetches one page of an events endpoint given relative to the
+// API root.
+func getEventsPage(ctx context.Context, endpoint string, perPageEvents int, page int, opts Options) ([]Event, []EventDiagnostic, error) {
+	url := fmt.Sprintf("%s/%s?per_page=%d&page=%d", opts.githubAPIURL(), endpoint, perPageEvents, page)
 	log.Printf("Making HTTP GET request to URL: %s", url)
 
-	// Set up HTTP client with timeout
-	resp, err := makeGitHubRequest(url)
+	resp, err := makeGitHubRequest(ctx, url, opts)
 	if err != nil {
 		log.Printf("Error making HTTP request: %v", err)
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
@@ -187,235 +554,548 @@ func GetEvents(username string, perPageEvents int, page int) ([]map[string]inter
 		} else {
 			log.Printf("body: %s", body)
 		}
-		return nil, fmt.Errorf("failed to fetch activity: %s", resp.Status)
+		return nil, nil, fmt.Errorf("failed to fetch activity: %s", resp.Status)
 	}
 
-	var events []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+	events, diagnostics, err := DecodeEvents(resp.Body)
+	if err != nil {
 		log.Printf("Error decoding JSON response: %v", err)
-		return nil, err
+		return nil, nil, err
 	}
 
-	return events, nil
+	return events, diagnostics, nil
 }
 
+// pushCommits is the compare result of a push together with how each commit
+// relates to the pusher and the LLM summaries made for it in strict mode.
+type pushCommits struct {
+	commits     []RepoCommit
+	authorships []CommitAuthorship
+	// linkedToOthers is set when a commit in the range belongs to another
+	// account; commits with unlinked emails are then not credited.
+	linkedToOthers bool
+	// summaries holds commit summaries by index into commits.
+	summaries map[int]string
+}
+
+// newPushCommits classifies the commits of a push made by login.
+func newPushCommits(commits []RepoCommit, login string, authorEmails map[string]string) *pushCommits {
+	push := &pushCommits{
+		commits:     commits,
+		authorships: make([]CommitAuthorship, len(commits)),
+		summaries:   make(map[int]string),
+	}
+	for i, commit := range commits {
+		push.authorships[i] = ClassifyCommit(commit, login, authorEmails)
+		if !push.authorships[i].ByUser && !push.authorships[i].Unlinked {
+			push.linkedToOthers = true
+		}
+	}
+	return push
+}
+
+// byUser reports whether commit i is credited to the pusher.
+func (p *pushCommits) byUser(i int) bool {
+	authorship := p.authorships[i]
+	return (authorship.ByUser || (authorship.Unlinked && !p.linkedToOthers)) && !authorship.BotAuthored
+}
+
+// summarizable reports whether commit i is described by its own message and
+// can therefore be replaced by an LLM summary.
+func (p *pushCommits) summarizable(i int) bool {
+	return p.byUser(i) && !p.authorships[i].Merge && !p.authorships[i].BotCoAuthored
+}
+
+// pushEventMessages collects the messages of the commits in a push, using the
+// LLM commit summaries where they were made. push is nil when the commits
+// could not be fetched.
+func pushEventMessages(event Event, push *pushCommits) string {
+	if push == nil {
+		return ""
+	}
+	return push.messages(event.ID)
+}
+
+// payloadPushCommits converts the commits listed in a push payload, oldest
+// first, so they can be credited like the commits of the compare API.
+func payloadPushCommits(payload *PushEventPayload) []RepoCommit {
+	commits := make([]RepoCommit, 0, len(payload.Commits))
+	for _, pushCommit := range payload.Commits {
+		var commit RepoCommit
+		commit.SHA = pushCommit.SHA
+		commit.Commit.Message = pushCommit.Message
+		commit.Commit.Author = CommitSignature{Name: pushCommit.Author.Name, Email: pushCommit.Author.Email}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+// messages describes the commits credited to the user, oldest first, with
+// merges, bot co-authored commits and commits by others listed separately.
+func (p *pushCommits) messages(id string) string {
+	// The compare range can contain commits by other people, e.g. after a
+	// merge or force push. Only the user's own commits are described; the
+	// rest is reported as a count. Commits with unlinked emails are credited
+	// to the pusher unless the range also has commits by other accounts.
+	messages := ""
+	var merges, botCoAuthored []string
+	otherAuthors := 0
+	for i, commit := range p.commits {
+		message := commit.Commit.Message
+		switch {
+		case !p.byUser(i):
+			otherAuthors++
+			continue
+		case p.authorships[i].Merge:
+			merges = append(merges, firstLine(message))
+			continue
+		case p.authorships[i].BotCoAuthored:
+			botCoAuthored = append(botCoAuthored, firstLine(message))
+			continue
+		}
+
+		if summary, ok := p.summaries[i]; ok {
+			messages += fmt.Sprintf("Commit summary: %s\n", summary)
+		} else {
+			messages += indentMessage(message) + "\n"
+		}
+	}
+
+	for _, merge := range merges {
+		messages += fmt.Sprintf("Merge commit: %s\n", merge)
+	}
+	for _, message := range botCoAuthored {
+		messages += fmt.Sprintf("Commit co-authored with a bot: %s\n", message)
+	}
+	if otherAuthors > 0 {
+		log.Printf("[%s] Skipped %d commits by other authors", id, otherAuthors)
+		if messages != "" {
+			messages += fmt.Sprintf("The push also contained %s by other authors.\n", plural(otherAuthors, "commit"))
+		}
+	}
+	return messages
+}
+
+// fetchEventDetails makes the GitHub requests an event needs before its
+// content can be built: pull request details missing from trimmed payloads
+// and the commits of a push. It returns the push commits, if any.
+func fetchEventDetails(ctx context.Context, event Event, opts Options) *pushCommits {
+	if event.Repo.Name == "" || event.Repo.Name == PrivateRepositoryName {
+		return nil
+	}
+	switch payload := event.Payload.(type) {
+	case *PullRequestEventPayload:
+		if pullRequestActions[payload.Action] {
+			payload.PullRequest = completePullRequest(ctx, payload.PullRequest, opts)
+		}
+	case *PullRequestReviewEventPayload:
+		payload.PullRequest = completePullRequest(ctx, payload.PullRequest, opts)
+	case *PullRequestReviewCommentEventPayload:
+		payload.PullRequest = completePullRequest(ctx, payload.PullRequest, opts)
+	case *PushEventPayload:
+		if payload.Head == "" || payload.Before == "" {
+			// Without both SHAs the compare API cannot be used; fall back to
+			// the commits listed in the payload, if any.
+			log.Printf("[%s] Missing 'before' or 'head' SHA, using %d commits from the payload", event.ID, len(payload.Commits))
+			return newPushCommits(payloadPushCommits(payload), event.Actor.Login, opts.AuthorEmails)
+		}
+		commits, err := GetPushEventCommits(ctx, event.Repo.Name, payload.Before, payload.Head, opts)
+		if err != nil {
+			log.Printf("[%s] Error fetching commits from compare API: %v", event.ID, err)
+			return nil
+		}
+		return newPushCommits(commits, event.Actor.Login, opts.AuthorEmails)
+	}
+	return nil
+}
+
+// summarizePushCommits makes LLM summaries for the user's commits in strict
+// mode. Commits are picked in event order until maxCommitSummary summaries
+// were made overall, so the same events always get the same summaries; the
+// summaries themselves are generated concurrently.
+func summarizePushCommits(ctx context.Context, events []Event, pushes []*pushCommits, maxCommitSummary int, commitSummariesCount *int, opts Options) {
+	type commitRef struct{ push, commit int }
+	var selected []commitRef
+	for i, push := range pushes {
+		if push == nil {
+			continue
+		}
+		for j := range push.commits {
+			if push.summarizable(j) && *commitSummariesCount+len(selected) < maxCommitSummary {
+				selected = append(selected, commitRef{i, j})
+			}
+		}
+	}
+
+	summaries := make([]string, len(selected))
+	runConcurrently(ctx, len(selected), opts.concurrency(), func(k int) {
+		ref := selected[k]
+		event := events[ref.push]
+		summary, ok := GetCommitSummary(ctx, event.Repo.Name, pushes[ref.push].commits[ref.commit], opts)
+		if !ok {
+			log.Printf("[%s] Error generating commit summary", event.ID)
+			return
+		}
+		summaries[k] = summary
+	})
+	for k, ref := range selected {
+		if summaries[k] != "" {
+			pushes[ref.push].summaries[ref.commit] = summaries[k]
+			(*commitSummariesCount)++
+		}
+	}
+}
+
+// ProcessActivities turns events into activities until maxEvents activities
+// were collected. The GitHub and LLM requests of a batch of events run
+// concurrently, bounded by Options.Concurrency, while the activities are
+// appended in event order.
 func ProcessActivities(
-	events []map[string]interface{},
+	ctx context.Context,
+	events []Event,
 	maxEvents int,
 	mode string,
 	maxCommitSummary int,
 	activities *[]Activity,
 	repositories *map[string]struct{},
-	commitSummariesCount *int) {
-	for _, event := range events {
+	commitSummariesCount *int,
+	opts Options) {
+	for next := 0; next < len(events); {
+		if ctx.Err() != nil {
+			log.Printf("Stopping activity processing: %v", ctx.Err())
+			break
+		}
 		if len(*activities) >= maxEvents {
 			log.Printf("Reached maximum number of activities to process: %d", maxEvents)
 			break
 		}
 
-		id, ok := event["id"].(string)
+		// Fetch details for as many events as activities are still missing;
+		// events that yield no activity are made up for in the next batch.
+		batch := events[next:min(len(events), next+maxEvents-len(*activities))]
+		next += len(batch)
+
+		pushes := make([]*pushCommits, len(batch))
+		runConcurrently(ctx, len(batch), opts.concurrency(), func(i int) {
+			pushes[i] = fetchEventDetails(ctx, batch[i], opts)
+		})
+		if isStrictMode(mode) {
+			summarizePushCommits(ctx, batch, pushes, maxCommitSummary, commitSummariesCount, opts)
+		}
+		if ctx.Err() != nil {
+			log.Printf("Stopping activity processing: %v", ctx.Err())
+			break
+		}
+
+		for i, event := range batch {
+			activity, ok := eventActivity(event, pushes[i])
+			if !ok {
+				continue
+			}
+			if _, exists := (*repositories)[activity.Repository]; !exists && activity.Repository != PrivateRepositoryName {
+				(*repositories)[activity.Repository] = struct{}{}
+				log.Printf("[%s] Adding repository: %s", event.ID, activity.Repository)
+			}
+			*activities = append(*activities, activity)
+		}
+	}
+}
+
+// eventActivity builds the activity of an event whose details were fetched.
+func eventActivity(event Event, push *pushCommits) (Activity, bool) {
+	id := event.ID
+	repo := event.Repo.Name
+	if repo == "" {
+		log.Printf("[%s] Error getting repository name", id)
+		return Activity{}, false
+	}
+	if repo == PrivateRepositoryName {
+		content, ok := privateActivityDescriptions[event.Type]
 		if !ok {
-			log.Printf("Error parsing event ID")
+			log.Printf("[%s] Skipping private event type: %s", id, event.Type)
+		}
+		return Activity{Type: event.Type, Repository: repo, Content: content, Actor: event.Actor.Login, CreatedAt: event.CreatedAt}, ok
+	}
+	log.Printf("Processing event type: %s", event.Type)
+
+	var content string
+	var err error
+	switch payload := event.Payload.(type) {
+	case *IssueCommentEventPayload:
+		log.Printf("[%s] Processing IssueCommentEvent for repo: %s", id, repo)
+		content, err = GetIssueCommentEventContent(payload)
+	case *PullRequestEventPayload:
+		log.Printf("[%s] Processing PullRequestEvent for repo: %s", id, repo)
+		if !pullRequestActions[payload.Action] {
+			log.Printf("[%s] Skipping pull request action: %s", id, payload.Action)
+			return Activity{}, false
+		}
+		content, err = GetPullRequestEventContent(payload)
+	case *PullRequestReviewEventPayload:
+		log.Printf("[%s] Processing PullRequestReviewEvent for repo: %s", id, repo)
+		content, err = GetPullRequestReviewEventContent(payload)
+	case *PullRequestReviewCommentEventPayload:
+		log.Printf("[%s] Processing PullRequestReviewCommentEvent for repo: %s", id, repo)
+		content, err = GetPullRequestReviewCommentEventContent(payload)
+	case *IssuesEventPayload:
+		log.Printf("[%s] Processing IssuesEvent for repo: %s", id, repo)
+		content, err = GetIssuesEventContent(payload)
+	case *ReleaseEventPayload:
+		log.Printf("[%s] Processing ReleaseEvent for repo: %s", id, repo)
+		content, err = GetReleaseEventContent(payload)
+	case *CreateEventPayload:
+		log.Printf("[%s] Processing CreateEvent for repo: %s", id, repo)
+		content, err = GetCreateEventContent(payload)
+	case *DeleteEventPayload:
+		log.Printf("[%s] Processing DeleteEvent for repo: %s", id, repo)
+		content, err = GetDeleteEventContent(payload)
+	case *PushEventPayload:
+		log.Printf("[%s] Processing PushEvent for repo: %s", id, repo)
+		content = pushEventMessages(event, push)
+		if content == "" {
+			log.Printf("[%s] No commit messages found", id)
+			return Activity{}, false
+		}
+	case nil:
+		log.Printf("[%s] No decoded payload for event type: %s", id, event.Type)
+		return Activity{}, false
+	default:
+		log.Printf("[%s] Unsupported event type: %s", id, event.Type)
+		return Activity{}, false
+	}
+	if err != nil {
+		log.Printf("[%s] Error getting %s content: %v", id, event.Type, err)
+		return Activity{}, false
+	}
+	return Activity{Type: event.Type, Repository: repo, Content: content, Actor: event.Actor.Login, CreatedAt: event.CreatedAt}, true
+}
+
+// filterEventsByWindow keeps the events created within the window. Events
+// are listed newest first, so the second result reports that an event older
+// than Since was seen and further pages would be older still.
+func filterEventsByWindow(events []Event, window TimeWindow) ([]Event, bool) {
+	if window.IsZero() {
+		return events, false
+	}
+	kept := make([]Event, 0, len(events))
+	reachedSince := false
+	for _, event := range events {
+		if !window.Since.IsZero() && event.CreatedAt.Before(window.Since) {
+			reachedSince = true
 			continue
 		}
-		if eventType, ok := event["type"].(string); ok {
-			log.Printf("Processing event type: %s", eventType)
-			switch eventType {
-			case "IssueCommentEvent":
-				repo, err := GetRepositoryName(event)
-				if err != nil {
-					log.Printf("[%s] Error getting repository name", id)
-					continue
-				}
-				log.Printf("[%s] Processing IssueCommentEvent for repo: %s", id, repo)
-				payload, ok := event["payload"].(map[string]interface{})
-				if !ok {
-					log.Printf("[%s] Error parsing payload data", id)
-					continue
-				}
-				content, err := GetIssueCommentEventContent(payload)
-				if err != nil {
-					log.Printf("[%s] Error getting issue comment content: %v", id, err)
-					continue
-				}
-
-				if _, exists := (*repositories)[repo]; !exists {
-					(*repositories)[repo] = struct{}{}
-					log.Printf("[%s] Adding repository: %s", id, repo)
-				}
-				*activities = append(*activities, Activity{
-					Type:       eventType,
-					Repository: repo,
-					Content:    content,
-				})
-			case "PushEvent":
-				repo, err := GetRepositoryName(event)
-				if err != nil {
-					log.Printf("[%s] Error getting repository name", id)
-					continue
-				}
-				log.Printf("[%s] Processing PushEvent for repo: %s", id, repo)
-				payload, ok := event["payload"].(map[string]interface{})
-				if !ok {
-					log.Printf("[%s] Error parsing payload data", id)
-					continue
-				}
-
-				// Get before and after SHAs from the payload
-				before, ok := payload["before"].(string)
-				if !ok {
-					log.Printf("[%s] Error parsing 'before' SHA", id)
-					continue
-				}
-				after, ok := payload["head"].(string)
-				if !ok {
-					log.Printf("[%s] Error parsing 'head' SHA", id)
-					continue
-				}
-
-				// Fetch commits using the compare API
-				commits, err := GetPushEventCommits(repo, before, after)
-				if err != nil {
-					log.Printf("[%s] Error fetching commits from compare API: %v", id, err)
-					continue
-				}
-
-				messages := ""
-				for _, commit := range commits {
-					commitData, ok := commit.(map[string]interface{})
-					if !ok {
-						log.Printf("[%s] Error parsing commit data", id)
-						continue
-					}
-
-					// Extract message from commit.commit.message structure
-					commitInfo, ok := commitData["commit"].(map[string]interface{})
-					if !ok {
-						log.Printf("[%s] Error parsing commit info", id)
-						continue
-					}
-					message, ok := commitInfo["message"].(string)
-					if !ok {
-						log.Printf("[%s] Error parsing commit message", id)
-						continue
-					}
-
-					if strings.EqualFold(mode, "strict") && *commitSummariesCount < maxCommitSummary {
-						// For strict mode, we need to create a compatible structure for GetCommitSummary
-						commitForSummary := map[string]interface{}{
-							"message": message,
-							"url":     commitData["url"],
-						}
-						commit_summary, ok := GetCommitSummary(commitForSummary)
-						if !ok {
-							log.Printf("[%s] Error generating commit summary", id)
-							messages += message + "\n"
-							continue
-						}
-
-						summary := fmt.Sprintf("Commit summary: %s", commit_summary)
-						messages += summary + "\n"
-						(*commitSummariesCount)++
-					} else {
-						messages += message + "\n"
-					}
-				}
-
-				if messages == "" {
-					log.Printf("[%s] No commit messages found", id)
-					continue
-				}
-
-				if _, exists := (*repositories)[repo]; !exists {
-					(*repositories)[repo] = struct{}{}
-					log.Printf("[%s] Adding repository: %s", id, repo)
-				}
-				*activities = append(*activities, Activity{
-					Type:       eventType,
-					Repository: repo,
-					Content:    messages,
-				})
-
-			default:
-				log.Printf("[%s] Unsupported event type: %s", id, eventType)
-			}
+		if window.Contains(event.CreatedAt) {
+			kept = append(kept, event)
 		}
 	}
+	if skipped := len(events) - len(kept); skipped > 0 {
+		log.Printf("Skipped %d events outside the period: %s", skipped, window)
+	}
+	return kept, reachedSince
 }
 
-func GetUserActivity(username string, maxEvents int, mode string) (string, error) {
+// degradeModeForBudget logs the pre-flight estimate of GitHub calls needed
+// for a page of events and falls back from strict to fast mode when the
+// remaining rate limit budget cannot cover it. Events of repositories
+// already skipped or anonymized by the filter are left out, and the
+// metadata of repositories in decisions is not fetched again.
+func degradeModeForBudget(events []Event, mode string, commitSummaries int, repositories map[string]struct{}, filter RepoFilter, decisions map[string]repoDecision, limiter *RateLimiter) string {
+	checked := make(map[string]struct{}, len(decisions))
+	for repo := range decisions {
+		checked[repo] = struct{}{}
+	}
+	kept := make([]Event, 0, len(events))
+	for _, event := range events {
+		if decision, decided := decisions[event.Repo.Name]; !decided || decision == repoKeep {
+			kept = append(kept, event)
+		}
+	}
+	events = kept
+	estimate := EstimateAPICalls(events, mode, commitSummaries, repositories, filter, checked)
+	remaining, known := limiter.Remaining()
+	if !known {
+		log.Printf("Pre-flight estimate: %d GitHub API calls (remaining budget unknown)", estimate)
+		return mode
+	}
+	log.Printf("Pre-flight estimate: %d GitHub API calls, %d remaining", estimate, remaining)
+	if estimate <= remaining {
+		return mode
+	}
+
+	if isStrictMode(mode) {
+		if fastEstimate := EstimateAPICalls(events, "fast", 0, repositories, filter, checked); fastEstimate <= remaining {
+			log.Printf("Not enough GitHub API budget for strict mode, using fast mode (%d calls)", fastEstimate)
+			return "fast"
+		}
+		mode = "fast"
+	}
+	log.Printf("Warning: estimated GitHub API calls exceed the remaining budget; some pushes or READMEs may be skipped")
+	return mode
+}
+
+// prepareActivityOptions resolves what fetching activity needs up front: the
+// summarizer in strict mode and a rate limiter seeded with the current budget.
+func prepareActivityOptions(ctx context.Context, mode string, opts Options) (Options, error) {
+	if isStrictMode(mode) {
+		// Strict mode summarizes commits while fetching, so the backend is needed up front.
+		var err error
+		if opts, err = opts.withSummarizer(); err != nil {
+			return opts, err
+		}
+	}
+	if opts.RateLimiter == nil {
+		opts.RateLimiter = NewRateLimiter()
+	}
+	if err := GetRateLimit(ctx, opts.RateLimiter, opts); err != nil {
+		log.Printf("Could not fetch the GitHub rate limit: %v", err)
+	}
+	return opts, nil
+}
+
+// GetUserActivity collects the recent activity of a GitHub user and formats
+// it for GenerateSummary.
+func GetUserActivity(ctx context.Context, username string, maxEvents int, mode string, opts Options) (string, error) {
+	return GetSourcesActivity(ctx, username, []ActivitySource{GitHubSource{Username: username}}, maxEvents, mode, opts)
+}
+
+// eventPager fetches one page of events from an events endpoint.
+type eventPager func(ctx context.Context, perPage int, page int, opts Options) ([]Event, []EventDiagnostic, error)
+
+// collectUserActivities pages through the user's events and turns them into
+// activities. opts must have been prepared with prepareActivityOptions.
+func collectUserActivities(ctx context.Context, username string, maxEvents int, mode string, opts Options) ([]Activity, map[string]struct{}, error) {
+	log.Printf("Fetching activity for user: %s with max events: %d in mode: %s", username, maxEvents, mode)
+	pager := func(ctx context.Context, perPage int, page int, opts Options) ([]Event, []EventDiagnostic, error) {
+		return GetEvents(ctx, username, perPage, page, opts)
+	}
+	return collectActivities(ctx, pager, maxEvents, mode, opts)
+}
+
+// collectActivities pages through an events endpoint and turns the events
+// into activities.
+func collectActivities(ctx context.Context, pager eventPager, maxEvents int, mode string, opts Options) ([]Activity, map[string]struct{}, error) {
 	maxEvents = min(maxEvents, 100) // Limit to 100 events. Pagination is implemented below.
 	maxCommitSummary := 10          // Limit the number of commit summaries as they need to be additionally processed.
-	log.Printf("Fetching activity for user: %s with max events: %d in mode: %s", username, maxEvents, mode)
+
 	minActivityCount := 10
 	activities := []Activity{}
 	repositories := make(map[string]struct{})
 	commitSummariesCount := 0
 	currentPage := 1
 
+	// With a start of the period, keep paging until the whole period is
+	// covered instead of stopping after minActivityCount activities.
+	window := opts.timeWindow()
+	if !window.Since.IsZero() {
+		minActivityCount = maxEvents
+	}
+	reachedSince := false
+	repoDecisions := make(map[string]repoDecision)
+
 	const maxPagesAllowed = 4 // As of today 11.11.2025 GitHub limits the number of pages to 3 for this endpoint.
-	for len(activities) < minActivityCount && currentPage < maxPagesAllowed {
-		log.Printf("Fetching page %d of events for user: %s", currentPage, username)
+	for len(activities) < minActivityCount && currentPage < maxPagesAllowed && !reachedSince {
+		log.Printf("Fetching page %d of events", currentPage)
 		log.Printf("Current number of activities: %d. Minimal number for activities: %d", len(activities), minActivityCount)
-		events, err := GetEvents(username, maxEvents, currentPage)
+		events, diagnostics, err := pager(ctx, maxEvents, currentPage, opts)
 
+		if errors.Is(err, ErrGitHubRateLimited) && len(activities) > 0 {
+			log.Printf("Rate limited, summarizing the %d activities fetched so far: %v", len(activities), err)
+			break
+		}
 		if err != nil {
 			log.Printf("Error making HTTP request: %v", err)
-			return "", err
+			return nil, nil, err
 		}
 
 		log.Printf("Successfully fetched %d events", len(events))
+		for _, diagnostic := range diagnostics {
+			log.Printf("Event diagnostic: %s", diagnostic)
+		}
+		events, reachedSince = filterEventsByWindow(events, window)
+		// Estimated before filtering, which may fetch repository metadata.
+		pageMode := degradeModeForBudget(events, mode, maxCommitSummary-commitSummariesCount, repositories, opts.RepoFilter, repoDecisions, opts.RateLimiter)
+		events = filterRepositories(ctx, events, opts, repoDecisions)
 
-		ProcessActivities(events, maxEvents, mode, maxCommitSummary, &activities, &repositories, &commitSummariesCount)
+		ProcessActivities(ctx, events, maxEvents, pageMode, maxCommitSummary, &activities, &repositories, &commitSummariesCount, opts)
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
 		currentPage++
 	}
+	return activities, repositories, nil
+}
 
-	recentActivities := fmt.Sprintf("Recent activities for user %s:\n", username)
-	recentActivities += "Information about the repositories:\n"
+// repositoryDescriptions returns the prompt section with the READMEs of the
+// repositories.
+func repositoryDescriptions(ctx context.Context, repositories map[string]struct{}, opts Options) (string, error) {
+	repoNames := make([]string, 0, len(repositories))
 	for repo := range repositories {
-		readmeURL := fmt.Sprintf("https://api.github.com/repos/%s/contents/README.md", repo)
-		resp, err := makeGitHubRequest(readmeURL)
+		repoNames = append(repoNames, repo)
+	}
+	readmes, err := githubReadmes(ctx, repoNames, opts)
+	if err != nil {
+		return "", err
+	}
+	return formatRepositoryDescriptions(readmes), nil
+}
+
+// githubReadmes fetches the READMEs of the repositories concurrently and
+// returns them by repository name, leaving out repositories without one.
+func githubReadmes(ctx context.Context, repoNames []string, opts Options) (map[string]string, error) {
+	readmes := make([]string, len(repoNames))
+	runConcurrently(ctx, len(repoNames), opts.concurrency(), func(i int) {
+		readme, err := GetRepositoryReadme(ctx, repoNames[i], opts)
 		if err != nil {
-			log.Printf("Error fetching README.md for repo %s: %v", repo, err)
-			continue
+			log.Printf("Error fetching README.md for repo %s: %v", repoNames[i], err)
+			return
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode == http.StatusOK {
-			response, err := io.ReadAll(resp.Body)
-			if err != nil {
-				log.Printf("Error reading response body for repo %s: %v", repo, err)
-				continue
-			}
-
-			var readmeContent map[string]interface{}
-			if err := json.Unmarshal(response, &readmeContent); err != nil {
-				log.Printf("Error decoding JSON response for repo %s: %v", repo, err)
-				continue
-			}
-			content, ok := readmeContent["content"].(string)
-			if !ok {
-				log.Printf("Error parsing README.md content for repo %s", repo)
-				continue
-			}
-
-			// Decode the base64 content
-			decodedContent, err := base64.StdEncoding.DecodeString(content)
-			if err != nil {
-				log.Printf("Error decoding base64 content for repo %s: %v", repo, err)
-				continue
-			}
-
-			recentActivities += fmt.Sprintf("%s repository description:\n%s\n\n", repo, decodedContent)
-		} else {
-			log.Printf("No README.md found for repo %s", repo)
+		if readme == "" {
+			log.Printf("No README.md found for repo %s", repoNames[i])
+			return
+		}
+		readmes[i] = readme
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	byName := make(map[string]string, len(repoNames))
+	for i, repo := range repoNames {
+		if readmes[i] != "" {
+			byName[repo] = readmes[i]
 		}
 	}
+	return byName, nil
+}
+
+// formatRepositoryDescriptions renders the descriptions in sorted order, so
+// the prompt does not depend on map iteration or request timing.
+func formatRepositoryDescriptions(descriptions map[string]string) string {
+	formatted := "Information about the repositories:\n"
+	repoNames := make([]string, 0, len(descriptions))
+	for repo := range descriptions {
+		repoNames = append(repoNames, repo)
+	}
+	sort.Strings(repoNames)
+	for _, repo := range repoNames {
+		formatted += fmt.Sprintf("%s repository description:\n%s\n\n", repo, descriptions[repo])
+	}
+	return formatted
+}
 
+// formatActivities renders activities for the summary prompt. withActor adds
+// who performed each activity, for prompts that cover several people.
+func formatActivities(activities []Activity, withActor bool) string {
+	formatted := ""
 	for _, activity := range activities {
-		recentActivities += fmt.Sprintf("Type: %s\nRepository: %s\nContent: %s\n\n", activity.Type, activity.Repository, activity.Content)
+		if activity.Repository == "" {
+			formatted += fmt.Sprintf("Type: %s\nContent: %s\n\n", activity.Type, activity.Content)
+			continue
+		}
+		if withActor && activity.Actor != "" {
+			formatted += fmt.Sprintf("Type: %s\nBy: %s\nRepository: %s\nContent: %s\n\n", activity.Type, activity.Actor, activity.Repository, activity.Content)
+			continue
+		}
+		formatted += fmt.Sprintf("Type: %s\nRepository: %s\nContent: %s\n\n", activity.Type, activity.Repository, activity.Content)
 	}
-	log.Printf("User: %s", username)
-	log.Printf("Recent activities:\n %s", recentActivities)
-	return recentActivities, nil
+	return formatted
 }
@@ -0,0 +1,443 @@
+package ghsummary
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetUserActivityStopsOnCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := GetUserActivity(ctx, "McCzarny", 10, "fast", Options{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestProcessActivitiesUsesTypedPayloads(t *testing.T) {
+	events, _, err := DecodeEvents(strings.NewReader(eventsFixture))
+	if err != nil {
+		t.Fatalf("DecodeEvents failed: %v", err)
+	}
+
+	// Only events that do not need further API calls.
+	events = []Event{events[1], events[2], events[3], events[4]}
+
+	activities := []Activity{}
+	repositories := map[string]struct{}{}
+	commitSummariesCount := 0
+	ProcessActivities(context.Background(), events, 100, "fast", 0, &activities, &repositories, &commitSummariesCount, Options{})
+
+	if len(activities) != 2 {
+		t.Fatalf("expected 2 activities, got %+v", activities)
+	}
+	if activities[0].Type != "IssueCommentEvent" || !strings.Contains(activities[0].Content, "Crash on start") {
+		t.Errorf("unexpected issue comment activity: %+v", activities[0])
+	}
+	if activities[1].Type != "PushEvent" || activities[1].Content != "Inline commit\n" {
+		t.Errorf("unexpected push activity: %+v", activities[1])
+	}
+	if _, ok := repositories["McCzarny/ghsummary"]; !ok {
+		t.Errorf("expected repository to be recorded, got %v", repositories)
+	}
+}
+
+func TestProcessActivitiesCreditsPayloadCommits(t *testing.T) {
+	events, _, err := DecodeEvents(strings.NewReader(`[
+		{"id": "1", "type": "PushEvent", "actor": {"login": "octo"}, "repo": {"name": "octo/app"}, "public": true,
+		 "payload": {"commits": [
+			{"sha": "1", "author": {"name": "Octo", "email": "octo@users.noreply.github.com"}, "message": "Add summary cache"},
+			{"sha": "2", "author": {"name": "Someone", "email": "7+someone@users.noreply.github.com"}, "message": "Upstream change"},
+			{"sha": "3", "author": {"name": "dependabot[bot]", "email": "49699333+dependabot[bot]@users.noreply.github.com"}, "message": "Bump deps"}
+		 ]}}
+	]`))
+	if err != nil {
+		t.Fatalf("DecodeEvents failed: %v", err)
+	}
+
+	activities := []Activity{}
+	repositories := map[string]struct{}{}
+	commitSummariesCount := 0
+	ProcessActivities(context.Background(), events, 100, "fast", 0, &activities, &repositories, &commitSummariesCount, Options{})
+
+	want := "Add summary cache\nThe push also contained 2 commits by other authors.\n"
+	if len(activities) != 1 || activities[0].Content != want {
+		t.Errorf("expected only the user's commit to be credited, got %+v", activities)
+	}
+}
+
+func TestGetPullRequestEventContent(t *testing.T) {
+	mergedAt := time.Date(2026, 7, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		payload PullRequestEventPayload
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "merged",
+			payload: PullRequestEventPayload{Action: "closed", PullRequest: PullRequest{
+				Number: 12, Title: "Add strict mode", Body: "Summarizes commit diffs.", MergedAt: &mergedAt,
+				Additions: 120, Deletions: 30, ChangedFiles: 5, Commits: 3,
+			}},
+			want: []string{"Pull request merged: #12 Add strict mode", "+120 -30 in 5 files, 3 commits", "Summarizes commit diffs."},
+		},
+		{
+			name:    "closed without merge",
+			payload: PullRequestEventPayload{Action: "closed", Number: 13, PullRequest: PullRequest{Title: "Experiment"}},
+			want:    []string{"Pull request closed without merging: #13 Experiment"},
+		},
+		{
+			name:    "draft opened",
+			payload: PullRequestEventPayload{Action: "opened", PullRequest: PullRequest{Number: 14, Title: "WIP", Draft: true}},
+			want:    []string{"Draft pull request opened: #14 WIP"},
+		},
+		{
+			name:    "labeled is ignored",
+			payload: PullRequestEventPayload{Action: "labeled"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := GetPullRequestEventContent(&tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Errorf("expected %q in content:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestGetPullRequestReviewContent(t *testing.T) {
+	pr := PullRequest{Number: 3, Title: "Fix parser"}
+
+	review, err := GetPullRequestReviewEventContent(&PullRequestReviewEventPayload{
+		Action: "created", PullRequest: pr, Review: Review{State: "changes_requested", Body: "Please add tests."},
+	})
+	if err != nil {
+		t.Fatalf("GetPullRequestReviewEventContent failed: %v", err)
+	}
+	if !strings.Contains(review, "Requested changes on pull request: #3 Fix parser") || !strings.Contains(review, "Please add tests.") {
+		t.Errorf("unexpected review content: %s", review)
+	}
+
+	comment, err := GetPullRequestReviewCommentEventContent(&PullRequestReviewCommentEventPayload{
+		Action: "created", PullRequest: pr, Comment: Comment{Path: "parser.go", Body: "Off by one here."},
+	})
+	if err != nil {
+		t.Fatalf("GetPullRequestReviewCommentEventContent failed: %v", err)
+	}
+	if !strings.Contains(comment, "File: parser.go") || !strings.Contains(comment, "Off by one here.") {
+		t.Errorf("unexpected review comment content: %s", comment)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if got := excerpt("  short  ", 10); got != "short" {
+		t.Errorf("expected short text to be kept, got %q", got)
+	}
+	if got := excerpt("zażółć gęślą", 3); got != "za…" {
+		t.Errorf("expected cut at rune boundary, got %q", got)
+	}
+}
+
+func TestRepositoryEventContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content func() (string, error)
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "issue opened",
+			content: func() (string, error) {
+				return GetIssuesEventContent(&IssuesEventPayload{Action: "opened", Issue: Issue{Number: 4, Title: "Support GitLab", Body: "We use GitLab too."}})
+			},
+			want: []string{"Issue opened: #4 Support GitLab", "We use GitLab too."},
+		},
+		{
+			name: "issue closed as not planned",
+			content: func() (string, error) {
+				return GetIssuesEventContent(&IssuesEventPayload{Action: "closed", Issue: Issue{Number: 5, Title: "Dark mode", StateReason: "not_planned"}})
+			},
+			want: []string{"Issue closed as not planned: #5 Dark mode"},
+		},
+		{
+			name: "release published",
+			content: func() (string, error) {
+				return GetReleaseEventContent(&ReleaseEventPayload{Action: "published", Release: Release{TagName: "v2.0.0", Name: "ghsummary 2.0", Body: "Adds strict mode."}})
+			},
+			want: []string{"Release published: ghsummary 2.0 (tag v2.0.0)", "Release notes:\nAdds strict mode."},
+		},
+		{
+			name: "repository created",
+			content: func() (string, error) {
+				return GetCreateEventContent(&CreateEventPayload{RefType: "repository", Description: "Profile summaries"})
+			},
+			want: []string{"Repository created", "Description: Profile summaries"},
+		},
+		{
+			name: "tag created",
+			content: func() (string, error) {
+				return GetCreateEventContent(&CreateEventPayload{RefType: "tag", Ref: "v2.0.0"})
+			},
+			want: []string{"Tag created: v2.0.0"},
+		},
+		{
+			name: "branch deleted",
+			content: func() (string, error) {
+				return GetDeleteEventContent(&DeleteEventPayload{RefType: "branch", Ref: "feature"})
+			},
+			want: []string{"Branch deleted: feature"},
+		},
+		{
+			name: "draft release is ignored",
+			content: func() (string, error) {
+				return GetReleaseEventContent(&ReleaseEventPayload{Action: "created"})
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := tt.content()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Errorf("expected %q in content:\n%s", want, content)
+				}
+			}
+		})
+	}
+}
+
+func TestGetIssueCommentEventContent(t *testing.T) {
+	mergedAt := time.Date(2026, 7, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		payload IssueCommentEventPayload
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "comment on issue",
+			payload: IssueCommentEventPayload{Action: "created",
+				Issue:   Issue{Number: 7, Title: "Crash on start", Body: "Issue body", State: "open", User: User{Login: "someone"}},
+				Comment: Comment{Body: "I can reproduce this on Linux."}},
+			want: []string{"Commented on issue #7: Crash on start (open)", "Opened by: someone", "Comment: I can reproduce this on Linux."},
+		},
+		{
+			name: "edited comment on merged pull request",
+			payload: IssueCommentEventPayload{Action: "edited",
+				Issue:   Issue{Number: 8, Title: "Add cache", State: "closed", PullRequest: &IssuePullRequest{MergedAt: &mergedAt}},
+				Comment: Comment{Body: "LGTM"}},
+			want: []string{"Edited a comment on pull request #8: Add cache (merged)", "Comment: LGTM"},
+		},
+		{
+			name:    "deleted comment",
+			payload: IssueCommentEventPayload{Action: "deleted", Issue: Issue{Title: "x"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := GetIssueCommentEventContent(&tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Errorf("expected %q in content:\n%s", want, content)
+				}
+			}
+			if strings.Contains(content, "Issue body") {
+				t.Errorf("expected the issue body to be left out:\n%s", content)
+			}
+		})
+	}
+}
+
+// newFakeGitHubServer serves canned responses keyed by request path and query.
+func newFakeGitHubServer(t *testing.T, responses map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			body, ok = responses[r.URL.Path]
+		}
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetUserActivityUsesConfiguredAPIURL(t *testing.T) {
+	readme := base64.StdEncoding.EncodeToString([]byte("A tool that summarizes things."))
+	server := newFakeGitHubServer(t, map[string]string{
+		"/api/v3/users/octo/events?per_page=100&page=1": `[
+			{"id": "1", "type": "PushEvent", "actor": {"login": "octo"}, "repo": {"name": "octo/app"}, "public": true,
+			 "payload": {"before": "aaa", "head": "bbb"}}
+		]`,
+		"/api/v3/users/octo/events?per_page=100&page=2": `[]`,
+		"/api/v3/users/octo/events?per_page=100&page=3": `[]`,
+		"/api/v3/repos/octo/app/compare/aaa...bbb": `{"commits": [
+			{"sha": "1", "commit": {"message": "Add summary cache"}, "author": {"login": "octo"}, "parents": [{"sha": "0"}]},
+			{"sha": "2", "commit": {"message": "Upstream change"}, "author": {"login": "someone"}, "parents": [{"sha": "1"}]},
+			{"sha": "3", "commit": {"message": "Merge branch 'main'"}, "author": {"login": "octo"}, "parents": [{"sha": "1"}, {"sha": "2"}]}
+		]}`,
+		"/api/v3/repos/octo/app/contents/README.md": fmt.Sprintf(`{"encoding": "base64", "content": %q}`, readme),
+	})
+
+	activity, err := GetUserActivity(context.Background(), "octo", 100, "fast", Options{GitHubAPIURL: server.URL + "/api/v3/"})
+	if err != nil {
+		t.Fatalf("GetUserActivity failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"octo/app repository description:\nA tool that summarizes things.",
+		"Add summary cache",
+		"Merge commit: Merge branch 'main'",
+		"The push also contained 1 commit by other authors.",
+	} {
+		if !strings.Contains(activity, want) {
+			t.Errorf("expected %q in activity:\n%s", want, activity)
+		}
+	}
+	if strings.Contains(activity, "Upstream change") {
+		t.Errorf("expected commits by other authors to be left out:\n%s", activity)
+	}
+}
+
+func TestGitHubAPIURLFallsBackToEnvironment(t *testing.T) {
+	t.Setenv("GITHUB_API_URL", "https://ghe.example.com/api/v3/")
+	if got := (Options{}).githubAPIURL(); got != "https://ghe.example.com/api/v3" {
+		t.Errorf("expected URL from environment, got %q", got)
+	}
+
+	t.Setenv("GITHUB_API_URL", "")
+	if got := (Options{}).githubAPIURL(); got != DefaultGitHubAPIURL {
+		t.Errorf("expected default URL, got %q", got)
+	}
+}
+
+func TestProcessActivitiesConcurrentKeepsEventOrder(t *testing.T) {
+	const pushes = 6
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		var repo int
+		if _, err := fmt.Sscanf(r.URL.Path, "/repos/octo/r%d/", &repo); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		// Earlier repositories answer later, so completion order is reversed.
+		time.Sleep(time.Duration(pushes-repo) * 5 * time.Millisecond)
+		if strings.Contains(r.URL.Path, "/compare/") {
+			fmt.Fprintf(w, `{"commits": [{"sha": "c%d", "url": "%s/repos/octo/r%d/commits/c%d",
+				"commit": {"message": "Commit %d"}, "author": {"login": "octo"}, "parents": [{"sha": "p"}]}]}`,
+				repo, server.URL, repo, repo, repo)
+			return
+		}
+		fmt.Fprintf(w, `{"sha": "c%d", "files": [{"filename": "main.go", "patch": "+change %d"}]}`, repo, repo)
+	}))
+	t.Cleanup(server.Close)
+
+	var events []Event
+	for i := 0; i < pushes; i++ {
+		events = append(events, Event{
+			ID: fmt.Sprint(i), Type: "PushEvent", Actor: Actor{Login: "octo"},
+			Repo:    EventRepo{Name: fmt.Sprintf("octo/r%d", i)},
+			Payload: &PushEventPayload{Before: "a", Head: "b"},
+		})
+	}
+
+	activities := []Activity{}
+	repositories := map[string]struct{}{}
+	commitSummariesCount := 0
+	opts := Options{GitHubAPIURL: server.URL, Summarizer: &stubSummarizer{}, Concurrency: 2}
+	ProcessActivities(context.Background(), events, 100, "strict", 2, &activities, &repositories, &commitSummariesCount, opts)
+
+	if len(activities) != pushes {
+		t.Fatalf("expected %d activities, got %+v", pushes, activities)
+	}
+	for i, activity := range activities {
+		if activity.Repository != fmt.Sprintf("octo/r%d", i) {
+			t.Errorf("activity %d is for %s, expected event order", i, activity.Repository)
+		}
+		// Only the first two commits in event order are summarized.
+		summarized := strings.HasPrefix(activity.Content, "Commit summary:")
+		if summarized != (i < 2) {
+			t.Errorf("unexpected content for activity %d: %q", i, activity.Content)
+		}
+	}
+	if commitSummariesCount != 2 {
+		t.Errorf("expected 2 commit summaries, got %d", commitSummariesCount)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", maxInFlight)
+	}
+}
+
+func TestGetUserActivityFiltersByTimeWindow(t *testing.T) {
+	// Page 2 is not served: the event older than Since ends paging.
+	server := newFakeGitHubServer(t, map[string]string{
+		"/users/octo/events?per_page=100&page=1": `[
+			{"id": "3", "type": "IssuesEvent", "public": true, "created_at": "2026-10-16T10:00:00Z", "repo": {"name": "octo/app"},
+			 "payload": {"action": "opened", "issue": {"number": 3, "title": "Too new"}}},
+			{"id": "2", "type": "IssuesEvent", "public": true, "created_at": "2026-10-12T10:00:00Z", "repo": {"name": "octo/app"},
+			 "payload": {"action": "opened", "issue": {"number": 2, "title": "In the sprint"}}},
+			{"id": "1", "type": "IssuesEvent", "public": true, "created_at": "2026-09-30T10:00:00Z", "repo": {"name": "octo/app"},
+			 "payload": {"action": "opened", "issue": {"number": 1, "title": "Too old"}}}
+		]`,
+	})
+
+	window, err := ParseTimeWindow("2026-10-01", "2026-10-14", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{GitHubAPIURL: server.URL, Since: window.Since, Until: window.Until}
+	activity, err := GetUserActivity(context.Background(), "octo", 100, "fast", opts)
+	if err != nil {
+		t.Fatalf("GetUserActivity failed: %v", err)
+	}
+	if !strings.Contains(activity, "In the sprint") || !strings.Contains(activity, "Activity from 2026-10-01 to 2026-10-14") {
+		t.Errorf("expected the event and period in activity:\n%s", activity)
+	}
+	if strings.Contains(activity, "Too new") || strings.Contains(activity, "Too old") {
+		t.Errorf("expected events outside the period to be left out:\n%s", activity)
+	}
+}
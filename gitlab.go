@@ -0,0 +1,245 @@
+package ghsummary
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultGitLabURL is the root of gitlab.com.
+const DefaultGitLabURL = "https://gitlab.com"
+
+// GitLabSource is the activity of a user of gitlab.com or a self-hosted
+// GitLab instance, read from the /users/:id/events API. Pushes, merge
+// requests, issues and comments are mapped to the activity types of the
+// GitHub events feed. Repositories are named with the host of the instance,
+// e.g. "gitlab.example.com/group/project", which is also what RepoFilter
+// patterns are matched against. Push events only carry the title of the last
+// commit, so strict mode makes no difference.
+type GitLabSource struct {
+	// BaseURL is the root of the instance. Empty uses DefaultGitLabURL.
+	BaseURL string
+	// User is the user ID or username.
+	User string
+	// Token is a personal access token with the read_api scope. Without
+	// one only public activity is visible.
+	Token string
+}
+
+// gitLabEvent is an event as returned by the GitLab events API.
+type gitLabEvent struct {
+	ID             int64     `json:"id"`
+	ProjectID      int64     `json:"project_id"`
+	ActionName     string    `json:"action_name"`
+	TargetIID      int       `json:"target_iid"`
+	TargetType     string    `json:"target_type"`
+	TargetTitle    string    `json:"target_title"`
+	CreatedAt      time.Time `json:"created_at"`
+	AuthorUsername string    `json:"author_username"`
+	PushData       *struct {
+		CommitCount int    `json:"commit_count"`
+		Action      string `json:"action"`
+		RefType     string `json:"ref_type"`
+		Ref         string `json:"ref"`
+		CommitTitle string `json:"commit_title"`
+	} `json:"push_data"`
+	Note *struct {
+		Body         string `json:"body"`
+		NoteableType string `json:"noteable_type"`
+		NoteableIID  int    `json:"noteable_iid"`
+	} `json:"note"`
+}
+
+// gitLabProject is a project as returned by the GitLab projects API.
+type gitLabProject struct {
+	ID                int64  `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	Description       string `json:"description"`
+	Visibility        string `json:"visibility"`
+}
+
+func (s GitLabSource) Name() string {
+	return "GitLab user " + s.User + " on " + s.baseURL()
+}
+
+func (s GitLabSource) baseURL() string {
+	if s.BaseURL == "" {
+		return DefaultGitLabURL
+	}
+	return strings.TrimSuffix(s.BaseURL, "/")
+}
+
+func (s GitLabSource) get(ctx context.Context, endpoint string, out any) (bool, error) {
+	return getForgeJSON(ctx, s.baseURL()+"/api/v4/"+endpoint, "PRIVATE-TOKEN", s.Token, out)
+}
+
+func (s GitLabSource) Activities(ctx context.Context, maxEvents int, mode string, opts Options) ([]Activity, error) {
+	window := opts.timeWindow()
+	query := url.Values{}
+	// after and before are exclusive dates; the window is applied exactly below.
+	if !window.Since.IsZero() {
+		query.Set("after", window.Since.AddDate(0, 0, -1).Format(time.DateOnly))
+	}
+	if !window.Until.IsZero() {
+		query.Set("before", window.Until.AddDate(0, 0, 1).Format(time.DateOnly))
+	}
+
+	// Project names by ID; an empty name means the project is skipped.
+	projects := make(map[int64]string)
+	perPage := min(max(maxEvents, 1), 100)
+	const maxPages = 10
+	var activities []Activity
+	for page := 1; page <= maxPages && len(activities) < maxEvents; page++ {
+		query.Set("per_page", fmt.Sprint(perPage))
+		query.Set("page", fmt.Sprint(page))
+		log.Printf("Fetching page %d of GitLab events for %s", page, s.User)
+		var events []gitLabEvent
+		found, err := s.get(ctx, fmt.Sprintf("users/%s/events?%s", url.PathEscape(s.User), query.Encode()), &events)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("GitLab user %s not found", s.User)
+		}
+		for _, event := range events {
+			if len(activities) >= maxEvents {
+				break
+			}
+			if !window.Contains(event.CreatedAt) || event.ProjectID == 0 {
+				continue
+			}
+			repo, known := projects[event.ProjectID]
+			if !known {
+				repo = s.projectName(ctx, event.ProjectID, opts.RepoFilter)
+				projects[event.ProjectID] = repo
+			}
+			if repo == "" {
+				continue
+			}
+			activity, ok := gitLabEventActivity(event, repo)
+			if ok {
+				activity, ok = anonymizeForgeActivity(activity)
+			}
+			if !ok {
+				log.Printf("[gitlab %d] Skipping %s %s event", event.ID, event.ActionName, event.TargetType)
+				continue
+			}
+			activities = append(activities, activity)
+		}
+		if len(events) < perPage {
+			break
+		}
+	}
+	return activities, nil
+}
+
+// projectName resolves the project and applies the repository filter. It
+// returns an empty name for projects that are skipped.
+func (s GitLabSource) projectName(ctx context.Context, id int64, filter RepoFilter) string {
+	var project gitLabProject
+	found, err := s.get(ctx, fmt.Sprintf("projects/%d", id), &project)
+	if err != nil || !found {
+		log.Printf("Error fetching GitLab project %d: %v", id, err)
+		return ""
+	}
+	name, keep := filterForgeRepository(forgeRepositoryName(s.baseURL(), project.PathWithNamespace), project.Visibility != "public", filter)
+	if !keep {
+		return ""
+	}
+	return name
+}
+
+// gitLabEventActivity maps a GitLab event to the activity type of the
+// equivalent GitHub event.
+func gitLabEventActivity(event gitLabEvent, repo string) (Activity, bool) {
+	activity := Activity{Repository: repo, Actor: event.AuthorUsername, CreatedAt: event.CreatedAt}
+	if push := event.PushData; push != nil {
+		switch {
+		case push.Action == "removed":
+			activity.Type = "DeleteEvent"
+			activity.Content = fmt.Sprintf("Deleted %s %s\n", push.RefType, push.Ref)
+		case push.CommitCount == 0:
+			activity.Type = "CreateEvent"
+			activity.Content = fmt.Sprintf("Created %s %s\n", push.RefType, push.Ref)
+		default:
+			activity.Type = "PushEvent"
+			activity.Content = push.CommitTitle + "\n"
+			if push.CommitCount > 1 {
+				activity.Content += fmt.Sprintf("The push contained %s.\n", plural(push.CommitCount-1, "more commit"))
+			}
+		}
+		return activity, true
+	}
+
+	switch event.TargetType {
+	case "MergeRequest":
+		activity.Type = "PullRequestEvent"
+		var action string
+		switch event.ActionName {
+		case "opened":
+			action = "Merge request opened"
+		case "accepted":
+			action = "Merge request merged"
+		case "closed":
+			action = "Merge request closed without merging"
+		case "reopened":
+			action = "Merge request reopened"
+		case "approved":
+			activity.Type = "PullRequestReviewEvent"
+			action = "Approved merge request"
+		default:
+			return Activity{}, false
+		}
+		activity.Content = fmt.Sprintf("%s: !%d %s\n", action, event.TargetIID, event.TargetTitle)
+	case "Issue", "WorkItem":
+		activity.Type = "IssuesEvent"
+		switch event.ActionName {
+		case "opened", "closed", "reopened":
+			activity.Content = fmt.Sprintf("Issue %s: #%d %s\n", event.ActionName, event.TargetIID, event.TargetTitle)
+		default:
+			return Activity{}, false
+		}
+	case "Note", "DiffNote", "DiscussionNote":
+		note := event.Note
+		if note == nil {
+			return Activity{}, false
+		}
+		activity.Type = "IssueCommentEvent"
+		switch note.NoteableType {
+		case "MergeRequest":
+			activity.Content = fmt.Sprintf("Commented on merge request !%d: %s\n", note.NoteableIID, event.TargetTitle)
+		case "Issue":
+			activity.Content = fmt.Sprintf("Commented on issue #%d: %s\n", note.NoteableIID, event.TargetTitle)
+		default:
+			activity.Content = fmt.Sprintf("Commented on %s\n", strings.ToLower(note.NoteableType))
+		}
+		activity.Content += fmt.Sprintf("Comment: %s\n", excerpt(note.Body, maxBodyExcerpt))
+	default:
+		return Activity{}, false
+	}
+	return activity, true
+}
+
+// DescribeRepositories uses the descriptions of the GitLab projects.
+func (s GitLabSource) DescribeRepositories(ctx context.Context, repositories []string, opts Options) (map[string]string, error) {
+	prefix := forgeRepositoryName(s.baseURL(), "")
+	descriptions := make(map[string]string)
+	for _, repo := range repositories {
+		path, ok := strings.CutPrefix(repo, prefix)
+		if !ok {
+			continue
+		}
+		var project gitLabProject
+		if found, err := s.get(ctx, "projects/"+url.PathEscape(path), &project); err != nil || !found {
+			log.Printf("Error fetching GitLab project %s: %v", path, err)
+			continue
+		}
+		if project.Description != "" {
+			descriptions[repo] = project.Description
+		}
+	}
+	return descriptions, nil
+}
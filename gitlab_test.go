@@ -0,0 +1,148 @@
+package ghsummary
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const gitLabEventsFixture = `[
+	{"id": 5, "project_id": 1, "action_name": "commented on", "target_type": "DiffNote", "target_title": "Add search",
+	 "created_at": "2026-10-14T10:00:00Z", "author_username": "octo",
+	 "note": {"body": "Could this use the index?", "noteable_type": "MergeRequest", "noteable_iid": 7}},
+	{"id": 4, "project_id": 2, "action_name": "pushed to", "created_at": "2026-10-13T10:00:00Z", "author_username": "octo",
+	 "push_data": {"commit_count": 2, "action": "pushed", "ref_type": "branch", "ref": "main", "commit_title": "Rotate keys"}},
+	{"id": 3, "project_id": 1, "action_name": "accepted", "target_type": "MergeRequest", "target_iid": 6, "target_title": "Add search",
+	 "created_at": "2026-10-12T10:00:00Z", "author_username": "octo"},
+	{"id": 2, "project_id": 1, "action_name": "pushed to", "created_at": "2026-10-11T10:00:00Z", "author_username": "octo",
+	 "push_data": {"commit_count": 3, "action": "pushed", "ref_type": "branch", "ref": "search", "commit_title": "Index documents"}},
+	{"id": 1, "project_id": 1, "action_name": "opened", "target_type": "Issue", "target_iid": 3, "target_title": "Search is slow",
+	 "created_at": "2026-10-01T10:00:00Z", "author_username": "octo"}
+]`
+
+func newFakeGitLabServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			t.Errorf("expected the token in PRIVATE-TOKEN, got %q", r.Header.Get("PRIVATE-TOKEN"))
+		}
+		switch r.URL.Path {
+		case "/api/v4/users/octo/events":
+			if r.URL.Query().Get("page") != "1" {
+				fmt.Fprint(w, `[]`)
+				return
+			}
+			fmt.Fprint(w, gitLabEventsFixture)
+		case "/api/v4/projects/1", "/api/v4/projects/team/search":
+			fmt.Fprint(w, `{"id": 1, "path_with_namespace": "team/search", "description": "Full-text search.", "visibility": "internal"}`)
+		case "/api/v4/projects/2":
+			fmt.Fprint(w, `{"id": 2, "path_with_namespace": "team/vault", "description": "Secrets.", "visibility": "private"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGitLabSourceActivities(t *testing.T) {
+	server := newFakeGitLabServer(t)
+	host := strings.TrimPrefix(server.URL, "http://")
+	source := GitLabSource{BaseURL: server.URL, User: "octo", Token: "secret"}
+	opts := Options{
+		Since:      time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC),
+		RepoFilter: RepoFilter{Private: PrivateReposAnonymize},
+	}
+
+	activities, err := source.Activities(context.Background(), 100, "fast", opts)
+	if err != nil {
+		t.Fatalf("Activities failed: %v", err)
+	}
+
+	// Internal projects are not public, so the policy applies to them too.
+	want := []Activity{
+		{Type: "IssueCommentEvent", Repository: PrivateRepositoryName, Content: "Commented on an issue"},
+		{Type: "PushEvent", Repository: PrivateRepositoryName, Content: "Pushed commits"},
+		{Type: "PullRequestEvent", Repository: PrivateRepositoryName, Content: "Worked on a pull request"},
+		{Type: "PushEvent", Repository: PrivateRepositoryName, Content: "Pushed commits"},
+	}
+	if len(activities) != len(want) {
+		t.Fatalf("expected %d activities within the window, got %+v", len(want), activities)
+	}
+	for i, activity := range activities {
+		if activity.Type != want[i].Type || activity.Repository != want[i].Repository || activity.Content != want[i].Content {
+			t.Errorf("activity %d: expected %+v, got %+v", i, want[i], activity)
+		}
+	}
+
+	opts.RepoFilter = RepoFilter{Private: PrivateReposInclude, ExcludeRepos: []string{host + "/team/vault"}}
+	activities, err = source.Activities(context.Background(), 100, "fast", opts)
+	if err != nil {
+		t.Fatalf("Activities failed: %v", err)
+	}
+	want = []Activity{
+		{Type: "IssueCommentEvent", Content: "Commented on merge request !7: Add search\nComment: Could this use the index?\n"},
+		{Type: "PullRequestEvent", Content: "Merge request merged: !6 Add search\n"},
+		{Type: "PushEvent", Content: "Index documents\nThe push contained 2 more commits.\n"},
+	}
+	if len(activities) != len(want) {
+		t.Fatalf("expected %d activities without the excluded project, got %+v", len(want), activities)
+	}
+	for i, activity := range activities {
+		if activity.Type != want[i].Type || activity.Content != want[i].Content || activity.Repository != host+"/team/search" || activity.Actor != "octo" {
+			t.Errorf("activity %d: expected %+v in %s/team/search, got %+v", i, want[i], host, activity)
+		}
+	}
+
+	descriptions, err := source.DescribeRepositories(context.Background(), []string{host + "/team/search", "octo/app"}, opts)
+	if err != nil || len(descriptions) != 1 || descriptions[host+"/team/search"] != "Full-text search." {
+		t.Errorf("unexpected descriptions: %v, %v", descriptions, err)
+	}
+}
+
+func TestGetSourcesActivityMergesGitHubAndGitLab(t *testing.T) {
+	github := newFakeGitHubServer(t, map[string]string{
+		"/users/octo/events?per_page=100&page=1": `[
+			{"id": "2", "type": "IssuesEvent", "actor": {"login": "octo"}, "repo": {"name": "octo/app"}, "public": true, "created_at": "2026-10-13T12:00:00Z",
+			 "payload": {"action": "opened", "issue": {"number": 1, "title": "Crash on start"}}},
+			{"id": "1", "type": "IssuesEvent", "actor": {"login": "octo"}, "repo": {"name": "octo/app"}, "public": true, "created_at": "2026-10-11T12:00:00Z",
+			 "payload": {"action": "closed", "issue": {"number": 2, "title": "Typo"}}}
+		]`,
+		"/users/octo/events?per_page=100&page=2": `[]`,
+		"/users/octo/events?per_page=100&page=3": `[]`,
+	})
+	gitlab := newFakeGitLabServer(t)
+	host := strings.TrimPrefix(gitlab.URL, "http://")
+
+	sources := []ActivitySource{
+		GitHubSource{Username: "octo"},
+		GitLabSource{BaseURL: gitlab.URL, User: "octo", Token: "secret"},
+	}
+	opts := Options{
+		GitHubAPIURL: github.URL,
+		Since:        time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC),
+		RepoFilter:   RepoFilter{Private: PrivateReposInclude, ExcludeRepos: []string{host + "/team/vault"}},
+	}
+	activity, err := GetSourcesActivity(context.Background(), "octo", sources, 100, "fast", opts)
+	if err != nil {
+		t.Fatalf("GetSourcesActivity failed: %v", err)
+	}
+
+	if !strings.Contains(activity, host+"/team/search repository description:\nFull-text search.") {
+		t.Errorf("expected the GitLab project description in:\n%s", activity)
+	}
+	// Activities of both sources are interleaved newest first.
+	order := []string{"!7: Add search", "Issue opened: #1 Crash on start", "Merge request merged", "Issue closed: #2 Typo", "Index documents"}
+	last := -1
+	for _, want := range order {
+		i := strings.Index(activity, want)
+		if i < 0 || i < last {
+			t.Fatalf("expected %q after the previous activities in:\n%s", want, activity)
+		}
+		last = i
+	}
+}
@@ -0,0 +1,324 @@
+package ghsummary
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxGitLogCommits bounds the commits read from a local repository.
+const maxGitLogCommits = 1000
+
+// maxGitPatchBytes mirrors the GitHub commit API, which leaves out the patch
+// of very large diffs.
+const maxGitPatchBytes = 64 * 1024
+
+// localAuthor stands in for the login when a GitRepositorySource has none.
+// It cannot be a GitHub login, so it never matches a noreply address.
+const localAuthor = "local author"
+
+// GitRepositorySource reads the commits of a user from a local git
+// repository, such as an offline clone or a mirror of a repository that is
+// not hosted on GitHub. Commits on all local branches are considered and
+// grouped by day into PushEvent activities like the GitHub events feed.
+type GitRepositorySource struct {
+	// Path is the working tree or bare repository.
+	Path string
+	// Repository names the repository in the summary. Empty uses the base
+	// name of Path.
+	Repository string
+	// Login and AuthorEmails identify the user's commits: a commit is theirs
+	// when it is authored, committed or co-authored with one of
+	// AuthorEmails, an email mapped to Login in Options.AuthorEmails or
+	// Login's GitHub noreply address.
+	Login        string
+	AuthorEmails []string
+}
+
+func (s GitRepositorySource) Name() string {
+	return "git repository " + s.Path
+}
+
+func (s GitRepositorySource) repository() string {
+	if s.Repository != "" {
+		return s.Repository
+	}
+	path, err := filepath.Abs(s.Path)
+	if err != nil {
+		path = s.Path
+	}
+	return strings.TrimSuffix(filepath.Base(path), ".git")
+}
+
+// identity returns the login and email mapping ClassifyCommit matches the
+// user's commits with.
+func (s GitRepositorySource) identity(opts Options) (string, map[string]string) {
+	login := s.Login
+	if login == "" {
+		login = localAuthor
+	}
+	emails := make(map[string]string, len(opts.AuthorEmails)+len(s.AuthorEmails))
+	for email, mappedLogin := range opts.AuthorEmails {
+		emails[email] = mappedLogin
+	}
+	for _, email := range s.AuthorEmails {
+		emails[email] = login
+	}
+	return login, emails
+}
+
+func (s GitRepositorySource) Activities(ctx context.Context, maxEvents int, mode string, opts Options) ([]Activity, error) {
+	if s.Login == "" && len(s.AuthorEmails) == 0 {
+		return nil, errors.New("no login or author emails to match commits with")
+	}
+	repo := s.repository()
+	window := opts.timeWindow()
+	if !opts.RepoFilter.allowsName(repo) {
+		log.Printf("Skipping repository %s: excluded by filter", repo)
+		return nil, nil
+	}
+
+	commits, err := s.log(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	login, emails := s.identity(opts)
+	var own []RepoCommit
+	for _, commit := range commits {
+		authorship := ClassifyCommit(commit, login, emails)
+		if authorship.ByUser && !authorship.BotAuthored && window.Contains(commit.Commit.Author.Date) {
+			own = append(own, commit)
+		}
+	}
+	log.Printf("Found %d of %d commits by the user in %s", len(own), len(commits), repo)
+
+	// Commits of the same day form one activity, listed oldest first like
+	// the commits of a push.
+	var pushes []*pushCommits
+	var dates []time.Time
+	for start := 0; start < len(own) && len(pushes) < maxEvents; {
+		day := own[start].Commit.Author.Date.Format(time.DateOnly)
+		end := start + 1
+		for end < len(own) && own[end].Commit.Author.Date.Format(time.DateOnly) == day {
+			end++
+		}
+		group := make([]RepoCommit, 0, end-start)
+		for i := end - 1; i >= start; i-- {
+			group = append(group, own[i])
+		}
+		pushes = append(pushes, newPushCommits(group, login, emails))
+		dates = append(dates, own[start].Commit.Author.Date)
+		start = end
+	}
+
+	if isStrictMode(mode) {
+		s.summarizeCommits(ctx, repo, pushes, opts)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	activities := make([]Activity, 0, len(pushes))
+	for i, push := range pushes {
+		activities = append(activities, Activity{
+			Type:       "PushEvent",
+			Repository: repo,
+			Content:    push.messages(repo),
+			Actor:      s.Login,
+			CreatedAt:  dates[i],
+		})
+	}
+	return activities, nil
+}
+
+// summarizeCommits summarizes the first commits of the pushes from their
+// diffs, like ProcessActivities does in strict mode.
+func (s GitRepositorySource) summarizeCommits(ctx context.Context, repo string, pushes []*pushCommits, opts Options) {
+	const maxCommitSummary = 10
+	type commitRef struct{ push, commit int }
+	var selected []commitRef
+	for i, push := range pushes {
+		for j := range push.commits {
+			if push.summarizable(j) && len(selected) < maxCommitSummary {
+				selected = append(selected, commitRef{i, j})
+			}
+		}
+	}
+
+	summaries := make([]string, len(selected))
+	runConcurrently(ctx, len(selected), opts.concurrency(), func(k int) {
+		commit := pushes[selected[k].push].commits[selected[k].commit]
+		if summary, ok := cachedCommitSummary(repo, commit.SHA, opts); ok {
+			summaries[k] = summary
+			return
+		}
+		files, err := s.commitFiles(ctx, commit.SHA)
+		if err != nil {
+			log.Printf("Error reading the diff of commit %s: %v", commit.SHA, err)
+			return
+		}
+		if summary, ok := summarizeCommitFiles(ctx, repo, commit, files, opts); ok {
+			summaries[k] = summary
+		}
+	})
+	for k, ref := range selected {
+		if summaries[k] != "" {
+			pushes[ref.push].summaries[ref.commit] = summaries[k]
+		}
+	}
+}
+
+// DescribeRepositories uses the README.md at HEAD of the repository.
+func (s GitRepositorySource) DescribeRepositories(ctx context.Context, repositories []string, opts Options) (map[string]string, error) {
+	descriptions := make(map[string]string)
+	repo := s.repository()
+	for _, name := range repositories {
+		if name != repo {
+			continue
+		}
+		readme, err := s.git(ctx, "show", "HEAD:README.md")
+		if err != nil {
+			log.Printf("No README.md found for repo %s: %v", repo, err)
+			continue
+		}
+		if readme != "" {
+			descriptions[repo] = readme
+		}
+	}
+	return descriptions, nil
+}
+
+// git runs a git command in the repository and returns its output.
+func (s GitRepositorySource) git(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", s.Path}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(output), nil
+}
+
+// Fields of a commit in the git log output, separated by the ASCII unit
+// separator, with commits separated by the record separator.
+const gitLogFormat = "--format=%H%x1f%P%x1f%an%x1f%ae%x1f%aI%x1f%cn%x1f%ce%x1f%cI%x1f%B%x1e"
+
+// log reads the commits of all local branches, newest first by author date.
+// Commits committed before the start of the window are not read.
+func (s GitRepositorySource) log(ctx context.Context, window TimeWindow) ([]RepoCommit, error) {
+	args := []string{"log", "--branches", fmt.Sprintf("--max-count=%d", maxGitLogCommits), gitLogFormat}
+	if !window.Since.IsZero() {
+		args = append(args, "--since="+window.Since.Format(time.RFC3339))
+	}
+	output, err := s.git(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := parseGitLog(output)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].Commit.Author.Date.After(commits[j].Commit.Author.Date)
+	})
+	return commits, nil
+}
+
+func parseGitLog(output string) ([]RepoCommit, error) {
+	var commits []RepoCommit
+	for _, record := range strings.Split(output, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x1f", 9)
+		if len(fields) != 9 {
+			return nil, fmt.Errorf("unexpected git log record %q", record)
+		}
+		var commit RepoCommit
+		commit.SHA = fields[0]
+		for _, parent := range strings.Fields(fields[1]) {
+			commit.Parents = append(commit.Parents, struct {
+				SHA string `json:"sha"`
+			}{SHA: parent})
+		}
+		authorDate, err := time.Parse(time.RFC3339, fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("parsing author date of %s: %w", commit.SHA, err)
+		}
+		committerDate, err := time.Parse(time.RFC3339, fields[7])
+		if err != nil {
+			return nil, fmt.Errorf("parsing committer date of %s: %w", commit.SHA, err)
+		}
+		commit.Commit.Author = CommitSignature{Name: fields[2], Email: fields[3], Date: authorDate}
+		commit.Commit.Committer = CommitSignature{Name: fields[5], Email: fields[6], Date: committerDate}
+		commit.Commit.Message = strings.TrimRight(fields[8], "\n")
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// commitFiles returns the files changed by a commit with their patches in
+// the format of the GitHub commit API, which starts at the first hunk.
+func (s GitRepositorySource) commitFiles(ctx context.Context, sha string) ([]CommitFile, error) {
+	output, err := s.git(ctx, "show", "--format=", "--patch", "--no-color", "--no-ext-diff", "--no-renames", sha)
+	if err != nil {
+		return nil, err
+	}
+	return parseGitPatch(output), nil
+}
+
+func parseGitPatch(output string) []CommitFile {
+	var files []CommitFile
+	for _, section := range strings.Split("\n"+output, "\ndiff --git ")[1:] {
+		var file CommitFile
+		header, patch, _ := strings.Cut(section, "\n@@")
+		for _, line := range strings.Split(header, "\n") {
+			switch {
+			case strings.HasPrefix(line, "+++ b/"):
+				file.Filename = strings.TrimPrefix(line, "+++ b/")
+			case strings.HasPrefix(line, "--- a/") && file.Filename == "":
+				file.Filename = strings.TrimPrefix(line, "--- a/")
+			case strings.HasPrefix(line, "new file"):
+				file.Status = "added"
+			case strings.HasPrefix(line, "deleted file"):
+				file.Status = "removed"
+			}
+		}
+		if file.Filename == "" {
+			// Binary files have no ---/+++ lines; use the header "a/x b/x".
+			first, _, _ := strings.Cut(header, "\n")
+			if _, name, ok := strings.Cut(first, " b/"); ok {
+				file.Filename = name
+			}
+		}
+		if file.Status == "" {
+			file.Status = "modified"
+		}
+		if patch != "" {
+			patch = "@@" + strings.TrimRight(patch, "\n")
+			for _, line := range strings.Split(patch, "\n") {
+				switch {
+				case strings.HasPrefix(line, "+"):
+					file.Additions++
+				case strings.HasPrefix(line, "-"):
+					file.Deletions++
+				}
+			}
+			file.Changes = file.Additions + file.Deletions
+			if len(patch) <= maxGitPatchBytes {
+				file.Patch = patch
+			}
+		}
+		files = append(files, file)
+	}
+	return files
+}
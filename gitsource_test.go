@@ -0,0 +1,123 @@
+package ghsummary
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testGitRepo struct {
+	t    *testing.T
+	path string
+}
+
+// newTestGitRepo creates an empty repository in a temporary directory,
+// isolated from the user's git configuration.
+func newTestGitRepo(t *testing.T) *testGitRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	repo := &testGitRepo{t: t, path: filepath.Join(t.TempDir(), "tool")}
+	repo.run(nil, "init", "--quiet", "--initial-branch=main", repo.path)
+	return repo
+}
+
+func (r *testGitRepo) run(env []string, args ...string) {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = filepath.Dir(r.path)
+	cmd.Env = append(os.Environ(), env...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		r.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+}
+
+// commit writes the file and commits it as the author at the given time.
+func (r *testGitRepo) commit(author, file, content, message string, at time.Time) {
+	r.t.Helper()
+	if err := os.WriteFile(filepath.Join(r.path, file), []byte(content), 0o644); err != nil {
+		r.t.Fatal(err)
+	}
+	name, _, _ := strings.Cut(author, "@")
+	date := at.Format(time.RFC3339)
+	env := []string{
+		"GIT_AUTHOR_NAME=" + name, "GIT_AUTHOR_EMAIL=" + author, "GIT_AUTHOR_DATE=" + date,
+		"GIT_COMMITTER_NAME=" + name, "GIT_COMMITTER_EMAIL=" + author, "GIT_COMMITTER_DATE=" + date,
+	}
+	r.run(nil, "-C", r.path, "add", file)
+	r.run(env, "-C", r.path, "commit", "--quiet", "-m", message)
+}
+
+func TestGitRepositorySourceActivities(t *testing.T) {
+	repo := newTestGitRepo(t)
+	day := func(d, hour int) time.Time { return time.Date(2026, 10, d, hour, 0, 0, 0, time.UTC) }
+	repo.commit("octo@example.com", "old.go", "package old\n", "Old work", day(1, 9))
+	repo.commit("octo@example.com", "parser.go", "package parser\n", "Add parser", day(10, 9))
+	repo.commit("someone@example.com", "docs.md", "docs\n", "Write docs", day(10, 10))
+	repo.commit("octo@example.com", "parser.go", "package parser\n\nfunc Parse() {}\n", "Add Parse", day(10, 11))
+	repo.commit("work@example.com", "lexer.go", "package lexer\n", "Add lexer\n\nCo-authored-by: copilot[bot] <copilot[bot]@users.noreply.github.com>", day(12, 9))
+
+	source := GitRepositorySource{Path: repo.path, Login: "octo", AuthorEmails: []string{"octo@example.com"}}
+	opts := Options{
+		AuthorEmails: map[string]string{"work@example.com": "octo"},
+		Since:        day(5, 0),
+	}
+	activities, err := source.Activities(context.Background(), 100, "fast", opts)
+	if err != nil {
+		t.Fatalf("Activities failed: %v", err)
+	}
+
+	if len(activities) != 2 {
+		t.Fatalf("expected one activity per day, got %+v", activities)
+	}
+	for _, activity := range activities {
+		if activity.Type != "PushEvent" || activity.Repository != "tool" || activity.Actor != "octo" {
+			t.Errorf("unexpected activity: %+v", activity)
+		}
+	}
+	if !activities[0].CreatedAt.Equal(day(12, 9)) || activities[0].Content != "Commit co-authored with a bot: Add lexer\n" {
+		t.Errorf("expected the newest day first, got %+v", activities[0])
+	}
+	if activities[1].Content != "Add parser\nAdd Parse\n" {
+		t.Errorf("expected the user's commits of the day oldest first, got %q", activities[1].Content)
+	}
+}
+
+func TestGitRepositorySourceSummarizesDiffsInStrictMode(t *testing.T) {
+	repo := newTestGitRepo(t)
+	repo.commit("octo@example.com", "parser.go", "package parser\n", "Add parser", time.Date(2026, 10, 10, 9, 0, 0, 0, time.UTC))
+
+	source := GitRepositorySource{Path: repo.path, Repository: "octo/tool", AuthorEmails: []string{"octo@example.com"}}
+	activities, err := source.Activities(context.Background(), 100, "strict", Options{Summarizer: &stubSummarizer{}})
+	if err != nil {
+		t.Fatalf("Activities failed: %v", err)
+	}
+	want := "Commit summary: commit Commit message: Add parser\nFile: parser.go\nPatch:\n@@ -0,0 +1 @@\n+package parser\n"
+	if len(activities) != 1 || !strings.HasPrefix(activities[0].Content, want) {
+		t.Fatalf("expected a summary of the diff, got %+v", activities)
+	}
+}
+
+func TestGetSourcesActivityWithLocalRepository(t *testing.T) {
+	repo := newTestGitRepo(t)
+	repo.commit("octo@example.com", "README.md", "A tool for parsing.\n", "Add README", time.Date(2026, 10, 10, 9, 0, 0, 0, time.UTC))
+
+	sources := []ActivitySource{GitRepositorySource{Path: repo.path, AuthorEmails: []string{"octo@example.com"}}}
+	activity, err := GetSourcesActivity(context.Background(), "octo", sources, 100, "fast", Options{})
+	if err != nil {
+		t.Fatalf("GetSourcesActivity failed: %v", err)
+	}
+	want := "Recent activities for user octo:\n" +
+		"Information about the repositories:\ntool repository description:\nA tool for parsing.\n\n\n" +
+		"Type: PushEvent\nRepository: tool\nContent: Add README\n\n\n"
+	if activity != want {
+		t.Errorf("unexpected activity:\n%q\nwant:\n%q", activity, want)
+	}
+}
@@ -0,0 +1,406 @@
+package ghsummary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxContributionsPeriod is the longest period contributionsCollection
+// accepts in one query.
+const maxContributionsPeriod = 366 * 24 * time.Hour
+
+// maxContributionRepositories limits the repositories listed per
+// contribution type.
+const maxContributionRepositories = 25
+
+// githubGraphQLURL derives the GraphQL endpoint from the REST API root:
+// https://api.github.com/graphql on github.com and /api/graphql on GitHub
+// Enterprise Server.
+func (o Options) githubGraphQLURL() string {
+	url := o.githubAPIURL()
+	if root, found := strings.CutSuffix(url, "/api/v3"); found {
+		return root + "/api/graphql"
+	}
+	return url + "/graphql"
+}
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// makeGitHubGraphQLRequest runs a GraphQL query and decodes its data into
+// out. GraphQL has its own rate limit, so the request is not counted by the
+// REST RateLimiter.
+func makeGitHubGraphQLRequest(ctx context.Context, query string, variables map[string]any, out any, opts Options) error {
+	body, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.githubGraphQLURL(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	opts.RateLimiter = nil
+	client := &http.Client{Transport: opts.githubTransport()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GraphQL request failed: %s", resp.Status)
+	}
+
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("error decoding JSON response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		messages := make([]string, len(result.Errors))
+		for i, graphQLErr := range result.Errors {
+			messages[i] = graphQLErr.Message
+		}
+		return fmt.Errorf("GraphQL errors: %s", strings.Join(messages, "; "))
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+const contributionsQuery = `query($login: String!, $from: DateTime!, $to: DateTime!, $maxRepositories: Int!) {
+  user(login: $login) {
+    contributionsCollection(from: $from, to: $to) {
+      startedAt
+      endedAt
+      totalCommitContributions
+      totalIssueContributions
+      totalPullRequestContributions
+      totalPullRequestReviewContributions
+      totalRepositoryContributions
+      restrictedContributionsCount
+      contributionCalendar {
+        totalContributions
+        weeks { contributionDays { date contributionCount } }
+      }
+      commitContributionsByRepository(maxRepositories: $maxRepositories) { ...repositoryContributions }
+      pullRequestContributionsByRepository(maxRepositories: $maxRepositories) { ...repositoryContributions }
+      issueContributionsByRepository(maxRepositories: $maxRepositories) { ...repositoryContributions }
+      pullRequestReviewContributionsByRepository(maxRepositories: $maxRepositories) { ...repositoryContributions }
+    }
+  }
+}
+
+fragment repositoryContributions on ContributionsByRepository {
+  repository {
+    nameWithOwner
+    description
+    isPrivate
+    isFork
+    isArchived
+    repositoryTopics(first: 20) { nodes { topic { name } } }
+  }
+  contributions { totalCount }
+}`
+
+// ContributionRepository is a repository as returned by the GraphQL API.
+type ContributionRepository struct {
+	NameWithOwner    string `json:"nameWithOwner"`
+	Description      string `json:"description"`
+	IsPrivate        bool   `json:"isPrivate"`
+	IsFork           bool   `json:"isFork"`
+	IsArchived       bool   `json:"isArchived"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string `json:"name"`
+			} `json:"topic"`
+		} `json:"nodes"`
+	} `json:"repositoryTopics"`
+}
+
+// RepositoryContributions counts a user's contributions of one type to a
+// repository.
+type RepositoryContributions struct {
+	Repository    ContributionRepository `json:"repository"`
+	Contributions struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"contributions"`
+}
+
+// ContributionDay is a day of the contribution calendar.
+type ContributionDay struct {
+	Date              string `json:"date"`
+	ContributionCount int    `json:"contributionCount"`
+}
+
+// ContributionsCollection is the contributionsCollection of a user.
+type ContributionsCollection struct {
+	StartedAt                           time.Time `json:"startedAt"`
+	EndedAt                             time.Time `json:"endedAt"`
+	TotalCommitContributions            int       `json:"totalCommitContributions"`
+	TotalIssueContributions             int       `json:"totalIssueContributions"`
+	TotalPullRequestContributions       int       `json:"totalPullRequestContributions"`
+	TotalPullRequestReviewContributions int       `json:"totalPullRequestReviewContributions"`
+	TotalRepositoryContributions        int       `json:"totalRepositoryContributions"`
+	// RestrictedContributionsCount counts private contributions the token
+	// cannot see in detail.
+	RestrictedContributionsCount int `json:"restrictedContributionsCount"`
+	ContributionCalendar         struct {
+		TotalContributions int `json:"totalContributions"`
+		Weeks              []struct {
+			ContributionDays []ContributionDay `json:"contributionDays"`
+		} `json:"weeks"`
+	} `json:"contributionCalendar"`
+	CommitContributionsByRepository            []RepositoryContributions `json:"commitContributionsByRepository"`
+	PullRequestContributionsByRepository       []RepositoryContributions `json:"pullRequestContributionsByRepository"`
+	IssueContributionsByRepository             []RepositoryContributions `json:"issueContributionsByRepository"`
+	PullRequestReviewContributionsByRepository []RepositoryContributions `json:"pullRequestReviewContributionsByRepository"`
+}
+
+// Days returns the calendar days in order.
+func (c *ContributionsCollection) Days() []ContributionDay {
+	var days []ContributionDay
+	for _, week := range c.ContributionCalendar.Weeks {
+		days = append(days, week.ContributionDays...)
+	}
+	return days
+}
+
+// contributionsWindow resolves the period of a contributions query. Without
+// bounds it covers the year before now; a period longer than a year is
+// rejected because GitHub does not support it.
+func contributionsWindow(window TimeWindow, now time.Time) (TimeWindow, error) {
+	if window.Until.IsZero() {
+		window.Until = now
+	}
+	if window.Since.IsZero() {
+		window.Since = window.Until.AddDate(-1, 0, 0)
+	}
+	if window.Until.Sub(window.Since) > maxContributionsPeriod {
+		return window, errors.New("the contributions calendar covers at most one year")
+	}
+	return window, nil
+}
+
+// GetContributionsCollection fetches the user's contributions in the period
+// given by opts.Since and opts.Until.
+func GetContributionsCollection(ctx context.Context, username string, opts Options) (*ContributionsCollection, error) {
+	window, err := contributionsWindow(opts.timeWindow(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Fetching contributions of %s from %s to %s", username, window.Since.Format(time.DateOnly), window.Until.Format(time.DateOnly))
+
+	var data struct {
+		User *struct {
+			ContributionsCollection ContributionsCollection `json:"contributionsCollection"`
+		} `json:"user"`
+	}
+	variables := map[string]any{
+		"login":           username,
+		"from":            window.Since.UTC().Format(time.RFC3339),
+		"to":              window.Until.UTC().Format(time.RFC3339),
+		"maxRepositories": maxContributionRepositories,
+	}
+	if err := makeGitHubGraphQLRequest(ctx, contributionsQuery, variables, &data, opts); err != nil {
+		return nil, err
+	}
+	if data.User == nil {
+		return nil, fmt.Errorf("user %s not found", username)
+	}
+	return &data.User.ContributionsCollection, nil
+}
+
+// ContributionActivities turns a contributions collection into activities:
+// one per repository and contribution type, followed by the totals and a
+// description of the calendar. Repositories are filtered like events; with
+// the anonymize policy private repositories are merged into one activity.
+// Restricted contributions are left out of the totals unless the policy is
+// include, and so are the visible private ones under the exclude policy.
+// The calendar only has daily counts, which cannot be split, so its active
+// days and busiest months count them whatever the policy.
+func ContributionActivities(collection *ContributionsCollection, filter RepoFilter) []Activity {
+	var activities []Activity
+	byType := []struct {
+		activityType string
+		noun         string
+		repositories []RepositoryContributions
+		total        int
+	}{
+		{"CommitContributions", "commits", collection.CommitContributionsByRepository, collection.TotalCommitContributions},
+		{"PullRequestContributions", "pull requests opened", collection.PullRequestContributionsByRepository, collection.TotalPullRequestContributions},
+		{"IssueContributions", "issues opened", collection.IssueContributionsByRepository, collection.TotalIssueContributions},
+		{"PullRequestReviewContributions", "pull request reviews", collection.PullRequestReviewContributionsByRepository, collection.TotalPullRequestReviewContributions},
+	}
+	// The calendar total counts restricted contributions, which only the
+	// include policy lets into the summary.
+	total := collection.ContributionCalendar.TotalContributions
+	if filter.Private != PrivateReposInclude {
+		total -= collection.RestrictedContributionsCount
+	}
+	for i, contributions := range byType {
+		privateCount := 0
+		for _, entry := range contributions.repositories {
+			repo := entry.Repository
+			count := entry.Contributions.TotalCount
+			if repo.IsPrivate && filter.Private != PrivateReposInclude && filter.Private != PrivateReposAnonymize {
+				// Private contributions visible to the token are counted in
+				// the totals; take them out like their repositories.
+				byType[i].total -= count
+				total -= count
+				continue
+			}
+			if !filter.allowsName(repo.NameWithOwner) || filter.skipReason(repo.asRepository()) != "" {
+				continue
+			}
+			if repo.IsPrivate && filter.Private == PrivateReposAnonymize {
+				privateCount += count
+				continue
+			}
+			activities = append(activities, Activity{
+				Type:       contributions.activityType,
+				Repository: repo.NameWithOwner,
+				Content:    fmt.Sprintf("%d %s", count, contributions.noun),
+			})
+		}
+		if privateCount > 0 {
+			activities = append(activities, Activity{
+				Type:       contributions.activityType,
+				Repository: PrivateRepositoryName,
+				Content:    fmt.Sprintf("%d %s", privateCount, contributions.noun),
+			})
+		}
+	}
+
+	totals := fmt.Sprintf("%d contributions in total: %d commits, %d pull requests, %d issues, %d reviews, %d new repositories",
+		max(total, 0), max(byType[0].total, 0), max(byType[1].total, 0), max(byType[2].total, 0),
+		max(byType[3].total, 0), collection.TotalRepositoryContributions)
+	if collection.RestrictedContributionsCount > 0 && filter.Private == PrivateReposInclude {
+		totals += fmt.Sprintf(", including %d private contributions", collection.RestrictedContributionsCount)
+	}
+	activities = append(activities, Activity{Type: "ContributionTotals", Content: totals})
+	if calendar := describeCalendar(collection.Days()); calendar != "" {
+		activities = append(activities, Activity{Type: "ContributionCalendar", Content: calendar})
+	}
+	return activities
+}
+
+// asRepository converts the GraphQL repository for RepoFilter checks.
+func (r ContributionRepository) asRepository() *Repository {
+	repo := &Repository{FullName: r.NameWithOwner, Private: r.IsPrivate, Fork: r.IsFork, Archived: r.IsArchived}
+	for _, node := range r.RepositoryTopics.Nodes {
+		repo.Topics = append(repo.Topics, node.Topic.Name)
+	}
+	return repo
+}
+
+// describeCalendar summarizes the contribution calendar: active days, the
+// longest streak and the busiest months.
+func describeCalendar(days []ContributionDay) string {
+	if len(days) == 0 {
+		return ""
+	}
+	activeDays, streak, longestStreak := 0, 0, 0
+	months := make(map[string]int)
+	for _, day := range days {
+		if day.ContributionCount == 0 {
+			streak = 0
+			continue
+		}
+		activeDays++
+		streak++
+		longestStreak = max(longestStreak, streak)
+		if date, err := time.Parse(time.DateOnly, day.Date); err == nil {
+			months[date.Format("January 2006")] += day.ContributionCount
+		}
+	}
+	description := fmt.Sprintf("Active on %d of %d days, longest streak %d days", activeDays, len(days), longestStreak)
+
+	busiest := make([]string, 0, len(months))
+	for month := range months {
+		busiest = append(busiest, month)
+	}
+	sort.Slice(busiest, func(i, j int) bool {
+		if months[busiest[i]] != months[busiest[j]] {
+			return months[busiest[i]] > months[busiest[j]]
+		}
+		return busiest[i] < busiest[j]
+	})
+	if len(busiest) > 3 {
+		busiest = busiest[:3]
+	}
+	for i, month := range busiest {
+		busiest[i] = fmt.Sprintf("%s (%d)", month, months[month])
+	}
+	if len(busiest) > 0 {
+		description += ", busiest months: " + strings.Join(busiest, ", ")
+	}
+	return description
+}
+
+// GetUserContributions builds the summary prompt from the contributions
+// calendar instead of the events feed. Unlike GetUserActivity it covers up
+// to a year, given by opts.Since and opts.Until, with accurate totals. It
+// needs a GITHUB_TOKEN, as the GraphQL API does not allow anonymous access.
+func GetUserContributions(ctx context.Context, username string, opts Options) (string, error) {
+	collection, err := GetContributionsCollection(ctx, username, opts)
+	if err != nil {
+		return "", err
+	}
+	activities := ContributionActivities(collection, opts.RepoFilter)
+
+	period := TimeWindow{Since: collection.StartedAt, Until: collection.EndedAt}
+	recentActivities := fmt.Sprintf("Contributions of user %s:\n", username)
+	recentActivities += fmt.Sprintf("%s.\n", period)
+	recentActivities += "Information about the repositories:\n"
+	described := make(map[string]bool)
+	for _, entries := range [][]RepositoryContributions{
+		collection.CommitContributionsByRepository,
+		collection.PullRequestContributionsByRepository,
+		collection.IssueContributionsByRepository,
+		collection.PullRequestReviewContributionsByRepository,
+	} {
+		for _, entry := range entries {
+			repo := entry.Repository
+			if described[repo.NameWithOwner] || repo.Description == "" || !activityMentions(activities, repo.NameWithOwner) {
+				continue
+			}
+			described[repo.NameWithOwner] = true
+			recentActivities += fmt.Sprintf("%s repository description:\n%s\n\n", repo.NameWithOwner, repo.Description)
+		}
+	}
+	recentActivities += formatActivities(activities, false)
+	log.Printf("User: %s", username)
+	log.Printf("Contributions:\n %s", recentActivities)
+	return recentActivities, nil
+}
+
+func activityMentions(activities []Activity, repo string) bool {
+	for _, activity := range activities {
+		if activity.Repository == repo {
+			return true
+		}
+	}
+	return false
+}
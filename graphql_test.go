@@ -0,0 +1,144 @@
+package ghsummary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const contributionsFixture = `{"data": {"user": {"contributionsCollection": {
+	"startedAt": "2026-01-01T00:00:00Z",
+	"endedAt": "2027-01-01T00:00:00Z",
+	"totalCommitContributions": 412,
+	"totalIssueContributions": 9,
+	"totalPullRequestContributions": 37,
+	"totalPullRequestReviewContributions": 58,
+	"totalRepositoryContributions": 2,
+	"restrictedContributionsCount": 40,
+	"contributionCalendar": {"totalContributions": 556, "weeks": [
+		{"contributionDays": [
+			{"date": "2026-03-01", "contributionCount": 4},
+			{"date": "2026-03-02", "contributionCount": 6},
+			{"date": "2026-03-03", "contributionCount": 0}
+		]},
+		{"contributionDays": [
+			{"date": "2026-04-01", "contributionCount": 2}
+		]}
+	]},
+	"commitContributionsByRepository": [
+		{"repository": {"nameWithOwner": "octo/app", "description": "The app.", "repositoryTopics": {"nodes": []}}, "contributions": {"totalCount": 300}},
+		{"repository": {"nameWithOwner": "octo/fork", "isFork": true}, "contributions": {"totalCount": 50}},
+		{"repository": {"nameWithOwner": "octo/secret", "description": "Secret plans.", "isPrivate": true}, "contributions": {"totalCount": 62}}
+	],
+	"pullRequestContributionsByRepository": [
+		{"repository": {"nameWithOwner": "octo/app", "description": "The app."}, "contributions": {"totalCount": 37}}
+	],
+	"issueContributionsByRepository": [],
+	"pullRequestReviewContributionsByRepository": [
+		{"repository": {"nameWithOwner": "acme/api", "description": "Acme API.", "repositoryTopics": {"nodes": [{"topic": {"name": "work"}}]}}, "contributions": {"totalCount": 58}}
+	]
+}}}}`
+
+func TestGetUserContributionsUsesGraphQL(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "token")
+	var request graphQLRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/graphql" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("unexpected Authorization header: %q", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		fmt.Fprint(w, contributionsFixture)
+	}))
+	t.Cleanup(server.Close)
+
+	opts := Options{
+		GitHubAPIURL: server.URL + "/api/v3",
+		Since:        time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Until:        time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		RepoFilter:   RepoFilter{SkipForks: true, SkipTopics: []string{"work"}, Private: PrivateReposAnonymize},
+	}
+	activity, err := GetUserContributions(context.Background(), "octo", opts)
+	if err != nil {
+		t.Fatalf("GetUserContributions failed: %v", err)
+	}
+
+	if !strings.Contains(request.Query, "contributionsCollection") || request.Variables["login"] != "octo" || request.Variables["from"] != "2026-01-01T00:00:00Z" {
+		t.Errorf("unexpected GraphQL request: %+v", request)
+	}
+	for _, want := range []string{
+		"Contributions of user octo:\nActivity from 2026-01-01 to 2026-12-31.",
+		"octo/app repository description:\nThe app.",
+		"Type: CommitContributions\nRepository: octo/app\nContent: 300 commits",
+		"Type: PullRequestContributions\nRepository: octo/app\nContent: 37 pull requests opened",
+		"Repository: a private repository\nContent: 62 commits",
+		"516 contributions in total: 412 commits, 37 pull requests, 9 issues, 58 reviews, 2 new repositories\n",
+		"Active on 3 of 4 days, longest streak 2 days, busiest months: March 2026 (10), April 2026 (2)",
+	} {
+		if !strings.Contains(activity, want) {
+			t.Errorf("expected %q in activity:\n%s", want, activity)
+		}
+	}
+	for _, notWant := range []string{"octo/fork", "octo/secret", "Secret plans", "acme/api"} {
+		if strings.Contains(activity, notWant) {
+			t.Errorf("unexpected %q in activity:\n%s", notWant, activity)
+		}
+	}
+}
+
+func TestContributionActivitiesTotalsFollowPrivatePolicy(t *testing.T) {
+	// 3 commits to a private repository the token can see, 4 it cannot.
+	collection := &ContributionsCollection{TotalCommitContributions: 13, RestrictedContributionsCount: 4}
+	collection.ContributionCalendar.TotalContributions = 17
+	private := RepositoryContributions{Repository: ContributionRepository{NameWithOwner: "octo/secret", IsPrivate: true}}
+	private.Contributions.TotalCount = 3
+	collection.CommitContributionsByRepository = []RepositoryContributions{private}
+	for policy, want := range map[PrivateRepoPolicy]string{
+		PrivateReposExclude:   "10 contributions in total: 10 commits, 0 pull requests, 0 issues, 0 reviews, 0 new repositories",
+		PrivateReposAnonymize: "13 contributions in total: 13 commits, 0 pull requests, 0 issues, 0 reviews, 0 new repositories",
+		PrivateReposInclude:   "17 contributions in total: 13 commits, 0 pull requests, 0 issues, 0 reviews, 0 new repositories, including 4 private contributions",
+	} {
+		totals := ""
+		for _, activity := range ContributionActivities(collection, RepoFilter{Private: policy}) {
+			if activity.Type == "ContributionTotals" {
+				totals = activity.Content
+			}
+		}
+		if totals != want {
+			t.Errorf("%s: expected totals %q, got %q", policy, want, totals)
+		}
+	}
+}
+
+func TestGetContributionsCollectionReportsGraphQLErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"user": null}, "errors": [{"type": "NOT_FOUND", "message": "Could not resolve to a User"}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := GetContributionsCollection(context.Background(), "ghost", Options{GitHubAPIURL: server.URL})
+	if err == nil || !strings.Contains(err.Error(), "Could not resolve to a User") {
+		t.Fatalf("expected the GraphQL error, got %v", err)
+	}
+}
+
+func TestContributionsWindow(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	window, err := contributionsWindow(TimeWindow{}, now)
+	if err != nil || !window.Since.Equal(now.AddDate(-1, 0, 0)) || !window.Until.Equal(now) {
+		t.Errorf("expected the last year by default, got %+v, %v", window, err)
+	}
+	if _, err := contributionsWindow(TimeWindow{Since: now.AddDate(-2, 0, 0)}, now); err == nil {
+		t.Error("expected an error for a period longer than a year")
+	}
+}
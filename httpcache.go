@@ -0,0 +1,238 @@
+package ghsummary
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// HTTPCache is a persistent cache of GitHub responses. Responses with an
+// ETag are revalidated with If-None-Match, which GitHub answers with a 304
+// that does not count against the rate limit. Responses for URLs pinned to
+// commit SHAs never change and are served without any request.
+type HTTPCache struct {
+	Dir string
+}
+
+// httpCacheEntry is the on-disk representation of a cached response.
+type httpCacheEntry struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	StoredAt     time.Time   `json:"stored_at"`
+}
+
+// NewHTTPCache creates the cache directory if needed.
+func NewHTTPCache(dir string) (*HTTPCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating HTTP cache directory: %w", err)
+	}
+	return &HTTPCache{Dir: dir}, nil
+}
+
+// immutableURLPattern matches API URLs whose response is fixed by the SHAs
+// in the path: single commits and compares between two full SHAs.
+var immutableURLPattern = regexp.MustCompile(`/commits/[0-9a-f]{40}$|/compare/[0-9a-f]{40}\.\.\.[0-9a-f]{40}$`)
+
+// key identifies a cached response by URL and the credentials used, so
+// responses fetched with a token are never served to another identity.
+func (c *HTTPCache) key(req *http.Request) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s", req.Header.Get("Authorization"), req.URL.String())
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *HTTPCache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+func (c *HTTPCache) load(key string) (*httpCacheEntry, error) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry httpCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (c *HTTPCache) store(key string, entry *httpCacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path(key), data)
+}
+
+// Prune removes entries not used since olderThan and returns how many were
+// removed.
+func (c *HTTPCache) Prune(olderThan time.Time) (int, error) {
+	return pruneDir(c.Dir, olderThan)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place,
+// so concurrent readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// touchFile marks a cache file as used, so pruning keeps it.
+func touchFile(path string) {
+	now := time.Now()
+	os.Chtimes(path, now, now)
+}
+
+// pruneDir removes the JSON files in dir last modified before olderThan.
+func pruneDir(dir string, olderThan time.Time) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return removed, err
+		}
+		if info.ModTime().Before(olderThan) {
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// cacheTransport serves and stores GitHub responses through an HTTPCache.
+type cacheTransport struct {
+	next  http.RoundTripper
+	cache *HTTPCache
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.next.RoundTrip(req)
+	}
+
+	key := t.cache.key(req)
+	entry, err := t.cache.load(key)
+	if err != nil {
+		log.Printf("Ignoring unreadable cache entry for %s: %v", req.URL, err)
+		entry = nil
+	}
+
+	immutable := immutableURLPattern.MatchString(req.URL.Path)
+	if entry != nil && immutable {
+		log.Printf("Serving %s from cache", req.URL)
+		touchFile(t.cache.path(key))
+		return entry.response(req, nil), nil
+	}
+
+	if entry != nil {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		log.Printf("Not modified, serving %s from cache", req.URL)
+		resp.Body.Close()
+		touchFile(t.cache.path(key))
+		return entry.response(req, resp.Header), nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || (etag == "" && !immutable) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	stored := &httpCacheEntry{
+		URL:          req.URL.String(),
+		ETag:         etag,
+		LastModified: resp.Header.Get("Last-Modified"),
+		Header:       resp.Header.Clone(),
+		Body:         body,
+		StoredAt:     time.Now(),
+	}
+	if err := t.cache.store(key, stored); err != nil {
+		log.Printf("Error caching response for %s: %v", req.URL, err)
+	}
+	return resp, nil
+}
+
+// response rebuilds a 200 response from the entry. Headers of a 304
+// revalidation, such as the current rate limit, take precedence.
+func (e *httpCacheEntry) response(req *http.Request, fresh http.Header) *http.Response {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for name, values := range fresh {
+		header[name] = values
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,138 @@
+package ghsummary
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPCacheRevalidatesWithETag(t *testing.T) {
+	requests, notModified := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `[{"id": "1", "type": "WatchEvent", "repo": {"name": "octo/app"}, "payload": {"action": "started"}}]`)
+	}))
+	t.Cleanup(server.Close)
+
+	cache, err := NewHTTPCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{GitHubAPIURL: server.URL, HTTPCache: cache}
+
+	for i := 0; i < 2; i++ {
+		events, _, err := GetEvents(context.Background(), "octo", 10, 1, opts)
+		if err != nil {
+			t.Fatalf("GetEvents failed: %v", err)
+		}
+		if len(events) != 1 || events[0].ID != "1" {
+			t.Fatalf("unexpected events on call %d: %+v", i+1, events)
+		}
+	}
+	if requests != 2 || notModified != 1 {
+		t.Fatalf("expected a conditional second request, got %d requests and %d 304s", requests, notModified)
+	}
+}
+
+func TestHTTPCacheRevalidationsDoNotCountAgainstRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "50")
+		w.Header().Set("X-RateLimit-Reset", "4102444800")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `[{"id": "1", "type": "WatchEvent", "repo": {"name": "octo/app"}, "payload": {"action": "started"}}]`)
+	}))
+	t.Cleanup(server.Close)
+
+	cache, err := NewHTTPCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := NewRateLimiter()
+	opts := Options{GitHubAPIURL: server.URL, HTTPCache: cache, RateLimiter: limiter}
+
+	for i := 0; i < 5; i++ {
+		if _, _, err := GetEvents(context.Background(), "octo", 10, 1, opts); err != nil {
+			t.Fatalf("GetEvents failed: %v", err)
+		}
+	}
+	if stats := limiter.Stats(); stats.Calls != 1 || stats.Remaining != 50 {
+		t.Fatalf("expected 1 call and 50 remaining after 4 revalidations, got %s", stats)
+	}
+}
+
+func TestHTTPCacheServesImmutableResponsesWithoutRequest(t *testing.T) {
+	before, head := strings.Repeat("a", 40), strings.Repeat("b", 40)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/repos/octo/app/compare/"+before+"..."+head {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"commits": [{"sha": "`+head+`", "commit": {"message": "Add cache"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	cache, err := NewHTTPCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter := NewRateLimiter()
+	opts := Options{GitHubAPIURL: server.URL, HTTPCache: cache, RateLimiter: limiter}
+
+	for i := 0; i < 2; i++ {
+		commits, err := GetPushEventCommits(context.Background(), "octo/app", before, head, opts)
+		if err != nil {
+			t.Fatalf("GetPushEventCommits failed: %v", err)
+		}
+		if len(commits) != 1 || commits[0].Commit.Message != "Add cache" {
+			t.Fatalf("unexpected commits on call %d: %+v", i+1, commits)
+		}
+	}
+	if requests != 1 || limiter.Stats().Calls != 1 {
+		t.Fatalf("expected the second compare to come from the cache, got %d requests and %d calls", requests, limiter.Stats().Calls)
+	}
+}
+
+func TestHTTPCacheIsKeyedByToken(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") != "" {
+			t.Errorf("request with token %q reused another identity's ETag", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `[]`)
+	}))
+	t.Cleanup(server.Close)
+
+	cache, err := NewHTTPCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{GitHubAPIURL: server.URL, HTTPCache: cache}
+
+	for _, token := range []string{"first", "second"} {
+		t.Setenv("GITHUB_TOKEN", token)
+		if _, _, err := GetEvents(context.Background(), "octo", 10, 1, opts); err != nil {
+			t.Fatalf("GetEvents failed: %v", err)
+		}
+	}
+	if requests != 2 {
+		t.Fatalf("expected one request per token, got %d", requests)
+	}
+}
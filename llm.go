@@ -4,163 +4,189 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"os"
+	"sort"
 	"strings"
 	"time"
+)
+
+// Constants for LLM prompts
+const (
+	SystemPromptSummary = `Generate a concise summary (max 10 sentences) of the user's recent GitHub activity based on the provided data.
+You can start the summary directly with "<Username> recently...".
+Use the user's pronouns (%s) naturally only when needed for sentence structure; do not state the pronouns themselves.
+The output must be plain text only, with absolutely no formatting (no markdown, newlines, etc.), suitable for direct use within an SVG <text> element.
+Focus on key actions like commits, pull requests, reviews, issues, releases and new repositories. Avoid any introductory or explanatory text.`
+	SystemPromptSummaryCommit = `Generate a brief, max 4 sentence summary of commit content.`
+	SystemPromptTeamSummary   = `Generate a concise summary (max 10 sentences) of the recent GitHub activity of %s based on the provided data, which lists the activities of each member.
+Describe the collective work first, e.g. "The team recently shipped...", and attribute notable contributions to the members who made them by username.
+The output must be plain text only, with absolutely no formatting (no markdown, newlines, etc.), suitable for direct use within an SVG <text> element.
+Focus on key actions like commits, pull requests, reviews, issues, releases and new repositories. Avoid any introductory or explanatory text.`
+	SystemPromptRepositorySummary = `Generate a concise summary (max 10 sentences) of what recently happened in the GitHub repository %s based on the provided data.
+You can start the summary directly with "Recently in <repository>...". Mention who pushed changes, which pull requests were merged and which releases shipped, naming contributors by username.
+The output must be plain text only, with absolutely no formatting (no markdown, newlines, etc.), suitable for direct use within an SVG <text> element.
+Avoid any introductory or explanatory text.`
+	SystemPromptOrganizationSummary = `Generate a concise summary (max 10 sentences) of the recent GitHub activity in the %s organization based on the provided data, which groups the activities by repository, most active first.
+Describe what the organization worked on as a whole, highlighting the most active repositories, merged pull requests and releases, and credit contributors by username where it adds value.
+The output must be plain text only, with absolutely no formatting (no markdown, newlines, etc.), suitable for direct use within an SVG <text> element.
+Avoid any introductory or explanatory text.`
+)
 
-	"google.golang.org/genai"
+// DefaultProvider is the summarizer backend used when none is selected.
+const DefaultProvider = "gemini"
+
+// ErrUnknownProvider is returned by NewSummarizer for unregistered provider names.
+var ErrUnknownProvider = errors.New("unknown provider")
+
+// Errors returned by summarizer backends. Backends wrap them with provider
+// details, so match them with errors.Is.
+var (
+	// ErrQuotaExceeded means the provider rejected the request because a rate
+	// limit or quota was hit and retrying did not help.
+	ErrQuotaExceeded = errors.New("LLM quota exceeded")
+	// ErrProviderUnavailable means the provider could not be reached or
+	// failed to serve the request.
+	ErrProviderUnavailable = errors.New("LLM provider unavailable")
+	// ErrEmptyCompletion means the provider answered without any text.
+	ErrEmptyCompletion = errors.New("LLM returned an empty completion")
+	// ErrSafetyBlocked means the provider refused to answer because of its
+	// safety filters.
+	ErrSafetyBlocked = errors.New("LLM response blocked by safety filters")
 )
 
-type LLMRequest struct {
-	Prompt string `json:"prompt"`
+// Summarizer is implemented by every LLM backend that can turn collected
+// activity into a summary. Commit summaries are generated concurrently, so
+// implementations must be safe for concurrent use.
+type Summarizer interface {
+	// Summarize generates the profile summary for the given activity.
+	Summarize(ctx context.Context, activity string, pronouns string) (string, error)
+	// SummarizeCommit generates a short summary of a single commit's content.
+	SummarizeCommit(ctx context.Context, content string) (string, error)
 }
 
-type LLMResponse struct {
-	Summary string `json:"summary"`
+// TeamSummarizer is implemented by summarizers that can summarize the
+// collective activity of several users. GenerateTeamSummary falls back to
+// Summarize for summarizers that do not implement it.
+type TeamSummarizer interface {
+	SummarizeTeam(ctx context.Context, activity string, team string) (string, error)
 }
 
-type GeminiRequest struct {
-	Prompt string `json:"prompt"`
+// RepositorySummarizer is implemented by summarizers that can summarize the
+// activity of a repository. GenerateRepositorySummary falls back to
+// Summarize for summarizers that do not implement it.
+type RepositorySummarizer interface {
+	SummarizeRepository(ctx context.Context, activity string, repo string) (string, error)
 }
 
-type GeminiResponse struct {
-	Summary string `json:"summary"`
+// OrganizationSummarizer is implemented by summarizers that can summarize the
+// activity of an organization. GenerateOrganizationSummary falls back to
+// Summarize for summarizers that do not implement it.
+type OrganizationSummarizer interface {
+	SummarizeOrganization(ctx context.Context, activity string, org string) (string, error)
 }
 
-// Constants for Gemini API
-const (
-	SystemPromptSummary = `Generate a concise summary (max 10 sentences) of the user's recent GitHub activity based on the provided data.
-You can start the summary directly with "<Username> recently...".
-Use the user's pronouns (%s) naturally only when needed for sentence structure; do not state the pronouns themselves.
-The output must be plain text only, with absolutely no formatting (no markdown, newlines, etc.), suitable for direct use within an SVG <text> element.
-Focus on key actions like commits, pull requests, and issues. Avoid any introductory or explanatory text.`
-	SystemPromptSummaryCommit = `Generate a brief, max 4 sentence summary of commit content.`
-)
+// CommitModelNamer is implemented by summarizers that can name the model
+// behind SummarizeCommit, so cached commit summaries are not reused after
+// switching models.
+type CommitModelNamer interface {
+	CommitModelName() string
+}
+
+// ProviderFactory creates a ready-to-use Summarizer.
+type ProviderFactory func() (Summarizer, error)
 
-func GenerateSummary(activity string, pronouns ...string) (string, error) {
-	return GenerateSummaryWithRetry(activity, 0, pronouns...)
+var providers = map[string]ProviderFactory{
+	"gemini":   func() (Summarizer, error) { return NewGeminiSummarizer() },
+	"openai":   func() (Summarizer, error) { return NewOpenAISummarizer() },
+	"fake":     func() (Summarizer, error) { return NewFakeSummarizer() },
+	"template": func() (Summarizer, error) { return NewTemplateSummarizer("") },
 }
 
-func GenerateSummaryWithRetry(activity string, attempt int, pronouns ...string) (string, error) {
-	const maxRetries = 5
-	// If pronouns are provided, use them; otherwise default to "he/him"
-	pronounValue := "he/him"
-	if len(pronouns) > 0 && pronouns[0] != "" {
-		pronounValue = pronouns[0]
-	}
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return "", errors.New("GEMINI_API_KEY is not set in the environment")
-	}
+// RegisterProvider makes a summarizer backend selectable by name.
+// Registering an existing name replaces the previous factory.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providers[strings.ToLower(name)] = factory
+}
 
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  apiKey,
-		Backend: genai.BackendGeminiAPI,
-	})
-	if err != nil {
-		log.Fatal(err)
+// Providers returns the names of all registered summarizer backends.
+func Providers() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	log.Printf("Client created. Generating summary... (attempt %d)", attempt+1)
-	result, err := client.Models.GenerateContent(ctx,
-		"gemini-3.6-flash",
-		genai.Text(activity),
-		&genai.GenerateContentConfig{
-			SystemInstruction: &genai.Content{Parts: []*genai.Part{{Text: fmt.Sprintf(SystemPromptSummary, pronounValue)}}},
-		},
-	)
-	log.Printf("Summary generation completed.")
-
-	if err != nil {
-		// Check for server overload (503) or rate limit errors
-		errMsg := err.Error()
-		isOverloaded := strings.Contains(errMsg, "503") || strings.Contains(errMsg, "overloaded")
-		isRateLimit := strings.Contains(errMsg, "PerMinute")
-
-		if (isOverloaded || isRateLimit) && attempt < maxRetries {
-			// Exponential backoff: 2^attempt seconds (32s, 64s, 128s, 256s, 512s)
-			waitDuration := time.Duration(1<<uint(attempt)) * 32 * time.Second
-			if isRateLimit {
-				// For rate limits, wait at least 1 minute
-				waitDuration = time.Minute
-			}
-			log.Printf("Error: %s. Retrying attempt %d/%d after %v", errMsg, attempt+1, maxRetries, waitDuration)
-			time.Sleep(waitDuration)
-			return GenerateSummaryWithRetry(activity, attempt+1, pronouns...)
-		}
-		log.Fatal(err)
+// NewSummarizer creates the summarizer registered under the given provider
+// name. An empty name selects the GHSUMMARY_PROVIDER environment variable,
+// falling back to DefaultProvider.
+func NewSummarizer(provider string) (Summarizer, error) {
+	if provider == "" {
+		provider = os.Getenv("GHSUMMARY_PROVIDER")
 	}
-
-	summary := ""
-	for _, part := range result.Candidates[0].Content.Parts {
-		summary += part.Text
+	if provider == "" {
+		provider = DefaultProvider
 	}
-	if len(summary) == 0 {
-		return "", errors.New("no summary generated")
+	factory, ok := providers[strings.ToLower(provider)]
+	if !ok {
+		return nil, fmt.Errorf("%w %q (available: %s)", ErrUnknownProvider, provider, strings.Join(Providers(), ", "))
 	}
-	// Print the summary
-	log.Printf("Summary: %s", summary)
-
-	return summary, nil
+	return factory()
 }
 
-func GenerateCommitSummary(content string, attempt int) (string, error) {
-	const maxRetries = 5
-	apiKey := os.Getenv("GEMINI_API_KEY")
-
-	log.Printf("Generating commit summary... (attempt %d)", attempt+1)
-	if apiKey == "" {
-		return "", errors.New("GEMINI_API_KEY is not set in the environment")
+func GenerateSummary(ctx context.Context, summarizer Summarizer, activity string, pronouns ...string) (string, error) {
+	// If pronouns are provided, use them; otherwise default to "he/him"
+	pronounValue := "he/him"
+	if len(pronouns) > 0 && pronouns[0] != "" {
+		pronounValue = pronouns[0]
 	}
+	return summarizer.Summarize(ctx, activity, pronounValue)
+}
 
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
-		APIKey:  apiKey,
-		Backend: genai.BackendGeminiAPI,
-	})
-	if err != nil {
-		log.Fatal(err)
+// GenerateTeamSummary summarizes the activity collected by GetTeamActivity.
+// An empty team name refers to the members as "the team".
+func GenerateTeamSummary(ctx context.Context, summarizer Summarizer, activity string, team string) (string, error) {
+	if team == "" {
+		team = "the team"
 	}
-
-	result, err := client.Models.GenerateContent(ctx,
-		"gemini-3.5-flash-lite",
-		genai.Text(content),
-		&genai.GenerateContentConfig{
-			SystemInstruction: &genai.Content{Parts: []*genai.Part{{Text: SystemPromptSummaryCommit}}},
-		},
-	)
-	log.Printf("Commit summary generation completed.")
-
-	if err != nil {
-		errMsg := err.Error()
-		isOverloaded := strings.Contains(errMsg, "503") || strings.Contains(errMsg, "overloaded")
-		isRateLimit := strings.Contains(errMsg, "PerMinute")
-
-		if (isOverloaded || isRateLimit) && attempt < maxRetries {
-			// Exponential backoff: 2^attempt seconds (2s, 4s, 8s, 16s, 32s)
-			waitDuration := time.Duration(1<<uint(attempt)) * 2 * time.Second
-			if isRateLimit {
-				// For rate limits, wait at least 1 minute
-				waitDuration = time.Minute
-			}
-			log.Printf("Error: %s. Retrying attempt %d/%d after %v", errMsg, attempt+1, maxRetries, waitDuration)
-			time.Sleep(waitDuration)
-			return GenerateCommitSummary(content, attempt+1)
-		}
-		log.Fatal(err)
+	if teamSummarizer, ok := summarizer.(TeamSummarizer); ok {
+		return teamSummarizer.SummarizeTeam(ctx, activity, team)
 	}
+	return summarizer.Summarize(ctx, activity, "they/them")
+}
 
-	summary := ""
-	for _, part := range result.Candidates[0].Content.Parts {
-		summary += part.Text
+// GenerateRepositorySummary summarizes the activity collected by
+// GetRepositoryActivity.
+func GenerateRepositorySummary(ctx context.Context, summarizer Summarizer, activity string, repo string) (string, error) {
+	if repositorySummarizer, ok := summarizer.(RepositorySummarizer); ok {
+		return repositorySummarizer.SummarizeRepository(ctx, activity, repo)
 	}
-	if len(summary) == 0 {
-		return "", errors.New("no commit summary generated")
+	return summarizer.Summarize(ctx, activity, "they/them")
+}
+
+// GenerateOrganizationSummary summarizes the activity collected by
+// GetOrganizationActivity.
+func GenerateOrganizationSummary(ctx context.Context, summarizer Summarizer, activity string, org string) (string, error) {
+	if organizationSummarizer, ok := summarizer.(OrganizationSummarizer); ok {
+		return organizationSummarizer.SummarizeOrganization(ctx, activity, org)
 	}
-	// Print the summary
-	log.Printf("Commit Summary: %s", summary)
+	return summarizer.Summarize(ctx, activity, "they/them")
+}
 
-	return summary, nil
+func GenerateCommitSummary(ctx context.Context, summarizer Summarizer, content string) (string, error) {
+	return summarizer.SummarizeCommit(ctx, content)
+}
+
+// sleepContext waits for the given duration or until the context is done,
+// whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
@@ -0,0 +1,59 @@
+package ghsummary
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubSummarizer struct {
+	pronouns string
+}
+
+func (s *stubSummarizer) Summarize(ctx context.Context, activity string, pronouns string) (string, error) {
+	s.pronouns = pronouns
+	return "summary of " + activity, nil
+}
+
+func (s *stubSummarizer) SummarizeCommit(ctx context.Context, content string) (string, error) {
+	return "commit " + content, nil
+}
+
+func TestNewSummarizerUsesRegisteredProvider(t *testing.T) {
+	stub := &stubSummarizer{}
+	RegisterProvider("Stub", func() (Summarizer, error) { return stub, nil })
+	t.Cleanup(func() { delete(providers, "stub") })
+
+	summarizer, err := NewSummarizer("stub")
+	if err != nil {
+		t.Fatalf("NewSummarizer failed: %v", err)
+	}
+
+	summary, err := GenerateSummary(context.Background(), summarizer, "activity")
+	if err != nil {
+		t.Fatalf("GenerateSummary failed: %v", err)
+	}
+	if summary != "summary of activity" {
+		t.Fatalf("unexpected summary: %q", summary)
+	}
+	if stub.pronouns != "he/him" {
+		t.Fatalf("expected default pronouns, got %q", stub.pronouns)
+	}
+}
+
+func TestNewSummarizerRejectsUnknownProvider(t *testing.T) {
+	_, err := NewSummarizer("does-not-exist")
+	if !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("expected ErrUnknownProvider, got %v", err)
+	}
+}
+
+func TestSleepContextReturnsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := sleepContext(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package ghsummary
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Defaults for the OpenAI-compatible backend.
+const (
+	OpenAIDefaultBaseURL = "https://api.openai.com/v1"
+	OpenAIDefaultModel   = "gpt-4o-mini"
+)
+
+// OpenAISummarizer generates summaries with any server implementing the
+// OpenAI chat completions API, e.g. OpenAI, Ollama, llama.cpp or vLLM.
+type OpenAISummarizer struct {
+	// BaseURL is the API root, without the trailing /chat/completions.
+	BaseURL string
+	// APIKey is sent as a bearer token when set. Local servers usually need none.
+	APIKey string
+	// Model is used for the profile summary.
+	Model string
+	// CommitModel is used for commit summaries. Empty falls back to Model.
+	CommitModel string
+	// HTTPClient is used for requests. Nil means http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// NewOpenAISummarizer creates an OpenAI-compatible backend configured from
+// OPENAI_BASE_URL, OPENAI_API_KEY, OPENAI_MODEL and OPENAI_COMMIT_MODEL.
+func NewOpenAISummarizer() (*OpenAISummarizer, error) {
+	baseURL := os.Getenv("OPENAI_BASE_URL")
+	if baseURL == "" {
+		baseURL = OpenAIDefaultBaseURL
+	}
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" && baseURL == OpenAIDefaultBaseURL {
+		return nil, fmt.Errorf("%w: OPENAI_API_KEY is not set", ErrProviderUnavailable)
+	}
+	model := os.Getenv("OPENAI_MODEL")
+	if model == "" {
+		model = OpenAIDefaultModel
+	}
+
+	return &OpenAISummarizer{
+		BaseURL:     baseURL,
+		APIKey:      apiKey,
+		Model:       model,
+		CommitModel: os.Getenv("OPENAI_COMMIT_MODEL"),
+	}, nil
+}
+
+func (o *OpenAISummarizer) Summarize(ctx context.Context, activity string, pronouns string) (string, error) {
+	log.Printf("Generating summary...")
+	summary, err := o.completeWithRetry(ctx, o.Model, fmt.Sprintf(SystemPromptSummary, pronouns), activity, 32*time.Second, 0)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Summary: %s", summary)
+	return summary, nil
+}
+
+func (o *OpenAISummarizer) SummarizeTeam(ctx context.Context, activity string, team string) (string, error) {
+	log.Printf("Generating team summary...")
+	summary, err := o.completeWithRetry(ctx, o.Model, fmt.Sprintf(SystemPromptTeamSummary, team), activity, 32*time.Second, 0)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Summary: %s", summary)
+	return summary, nil
+}
+
+func (o *OpenAISummarizer) SummarizeRepository(ctx context.Context, activity string, repo string) (string, error) {
+	log.Printf("Generating repository summary...")
+	summary, err := o.completeWithRetry(ctx, o.Model, fmt.Sprintf(SystemPromptRepositorySummary, repo), activity, 32*time.Second, 0)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Summary: %s", summary)
+	return summary, nil
+}
+
+func (o *OpenAISummarizer) SummarizeOrganization(ctx context.Context, activity string, org string) (string, error) {
+	log.Printf("Generating organization summary...")
+	summary, err := o.completeWithRetry(ctx, o.Model, fmt.Sprintf(SystemPromptOrganizationSummary, org), activity, 32*time.Second, 0)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Summary: %s", summary)
+	return summary, nil
+}
+
+func (o *OpenAISummarizer) SummarizeCommit(ctx context.Context, content string) (string, error) {
+	log.Printf("Generating commit summary...")
+	summary, err := o.completeWithRetry(ctx, o.CommitModelName(), SystemPromptSummaryCommit, content, 2*time.Second, 0)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Commit Summary: %s", summary)
+	return summary, nil
+}
+
+// CommitModelName returns the model used for commit summaries.
+func (o *OpenAISummarizer) CommitModelName() string {
+	if o.CommitModel == "" {
+		return o.Model
+	}
+	return o.CommitModel
+}
+
+func (o *OpenAISummarizer) completeWithRetry(ctx context.Context, model, systemPrompt, content string, baseBackoff time.Duration, attempt int) (string, error) {
+	const maxRetries = 5
+
+	log.Printf("Calling %s (attempt %d)", model, attempt+1)
+	status, summary, err := o.complete(ctx, model, systemPrompt, content)
+	if err != nil {
+		retryable := status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+		if retryable && attempt < maxRetries {
+			waitDuration := time.Duration(1<<uint(attempt)) * baseBackoff
+			log.Printf("Error: %v. Retrying attempt %d/%d after %v", err, attempt+1, maxRetries, waitDuration)
+			if err := sleepContext(ctx, waitDuration); err != nil {
+				return "", err
+			}
+			return o.completeWithRetry(ctx, model, systemPrompt, content, baseBackoff, attempt+1)
+		}
+		return "", err
+	}
+	return summary, nil
+}
+
+// complete sends a single chat completion request and returns the HTTP status
+// alongside the result so the caller can decide whether to retry.
+func (o *OpenAISummarizer) complete(ctx context.Context, model, systemPrompt, content string) (int, string, error) {
+	body, err := json.Marshal(chatCompletionRequest{
+		Model: model,
+		Messages: []chatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: content},
+		},
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	url := strings.TrimSuffix(o.BaseURL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.APIKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", o.APIKey))
+	}
+
+	client := o.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return 0, "", ctxErr
+		}
+		return 0, "", fmt.Errorf("%w: %s: %v", ErrProviderUnavailable, model, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, "", fmt.Errorf("%w: %s: %v", ErrProviderUnavailable, model, err)
+	}
+
+	var completion chatCompletionResponse
+	decodeErr := json.Unmarshal(respBody, &completion)
+	if resp.StatusCode != http.StatusOK {
+		reason := resp.Status
+		if decodeErr == nil && completion.Error != nil {
+			reason += ": " + completion.Error.Message
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return resp.StatusCode, "", fmt.Errorf("%w: %s: %s", ErrQuotaExceeded, model, reason)
+		}
+		return resp.StatusCode, "", fmt.Errorf("%w: %s: %s", ErrProviderUnavailable, model, reason)
+	}
+	if decodeErr != nil {
+		return resp.StatusCode, "", fmt.Errorf("%w: %s: error decoding chat completion: %v", ErrProviderUnavailable, model, decodeErr)
+	}
+	if len(completion.Choices) == 0 {
+		return resp.StatusCode, "", fmt.Errorf("%w: %s returned no choices", ErrEmptyCompletion, model)
+	}
+
+	choice := completion.Choices[0]
+	if choice.FinishReason == "content_filter" {
+		return resp.StatusCode, "", fmt.Errorf("%w: %s stopped with content_filter", ErrSafetyBlocked, model)
+	}
+	summary := strings.TrimSpace(choice.Message.Content)
+	if summary == "" {
+		return resp.StatusCode, "", fmt.Errorf("%w: %s", ErrEmptyCompletion, model)
+	}
+	return resp.StatusCode, summary, nil
+}
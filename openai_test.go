@@ -0,0 +1,129 @@
+package ghsummary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newChatCompletionServer(t *testing.T, reply string, requests *[]chatCompletionRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("unexpected Authorization header: %q", got)
+		}
+		var req chatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		*requests = append(*requests, req)
+		fmt.Fprintf(w, `{"choices":[{"message":{"role":"assistant","content":%q}}]}`, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAISummarizerSummarize(t *testing.T) {
+	var requests []chatCompletionRequest
+	server := newChatCompletionServer(t, " McCzarny recently shipped things. ", &requests)
+
+	summarizer := &OpenAISummarizer{BaseURL: server.URL + "/v1", APIKey: "test-key", Model: "llama3"}
+	summary, err := summarizer.Summarize(context.Background(), "activity data", "they/them")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if summary != "McCzarny recently shipped things." {
+		t.Fatalf("unexpected summary: %q", summary)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(requests))
+	}
+	req := requests[0]
+	if req.Model != "llama3" {
+		t.Errorf("expected model llama3, got %q", req.Model)
+	}
+	if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[1].Content != "activity data" {
+		t.Fatalf("unexpected messages: %+v", req.Messages)
+	}
+	if !strings.Contains(req.Messages[0].Content, "they/them") {
+		t.Errorf("expected pronouns in system prompt, got %q", req.Messages[0].Content)
+	}
+}
+
+func TestOpenAISummarizerCommitModelFallsBackToModel(t *testing.T) {
+	var requests []chatCompletionRequest
+	server := newChatCompletionServer(t, "Adds a feature.", &requests)
+
+	summarizer := &OpenAISummarizer{BaseURL: server.URL + "/v1/", APIKey: "test-key", Model: "llama3"}
+	if _, err := summarizer.SummarizeCommit(context.Background(), "diff"); err != nil {
+		t.Fatalf("SummarizeCommit failed: %v", err)
+	}
+	if requests[0].Model != "llama3" {
+		t.Errorf("expected model llama3, got %q", requests[0].Model)
+	}
+	if requests[0].Messages[0].Content != SystemPromptSummaryCommit {
+		t.Errorf("expected commit system prompt, got %q", requests[0].Messages[0].Content)
+	}
+}
+
+func TestOpenAISummarizerReportsServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"model not found"}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	summarizer := &OpenAISummarizer{BaseURL: server.URL, Model: "missing"}
+	_, err := summarizer.Summarize(context.Background(), "activity", "he/him")
+	if err == nil || !strings.Contains(err.Error(), "model not found") {
+		t.Fatalf("expected server error message, got %v", err)
+	}
+}
+
+func TestOpenAISummarizerReturnsTypedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+	}{
+		{"no choices", http.StatusOK, `{"choices":[]}`, ErrEmptyCompletion},
+		{"empty content", http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":" "}}]}`, ErrEmptyCompletion},
+		{"content filter", http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":""},"finish_reason":"content_filter"}]}`, ErrSafetyBlocked},
+		{"server error", http.StatusInternalServerError, `oops`, ErrProviderUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			summarizer := &OpenAISummarizer{BaseURL: server.URL, Model: "m"}
+			_, err := summarizer.Summarize(context.Background(), "activity", "he/him")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNewOpenAISummarizerWithoutAPIKey(t *testing.T) {
+	t.Setenv("OPENAI_BASE_URL", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	if _, err := NewOpenAISummarizer(); !errors.Is(err, ErrProviderUnavailable) {
+		t.Errorf("expected ErrProviderUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package ghsummary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// DefaultMaxRepositories is the number of most active repositories an
+// organization summary covers when Options.MaxRepositories is not set.
+const DefaultMaxRepositories = 5
+
+// maxOrgReadmes caps the README requests of an organization summary; only
+// the most active repositories get one.
+const maxOrgReadmes = 3
+
+// GetOrganizationEvents fetches one page of the public events of an
+// organization.
+func GetOrganizationEvents(ctx context.Context, org string, perPageEvents int, page int, opts Options) ([]Event, []EventDiagnostic, error) {
+	log.Printf("Fetching %d events for %s organization. Page %d", perPageEvents, org, page)
+	return getEventsPage(ctx, fmt.Sprintf("orgs/%s/events", org), perPageEvents, page, opts)
+}
+
+// repositoryActivityCount is the number of events of a repository.
+type repositoryActivityCount struct {
+	Repository string
+	Events     int
+}
+
+// mostActiveRepositories counts events per repository and returns the counts
+// ordered by activity, most active first, with ties broken by name. Events
+// anonymized by the private repository policy do not name a repository, so
+// they are not ranked.
+func mostActiveRepositories(events []Event) []repositoryActivityCount {
+	counts := make(map[string]int)
+	for _, event := range events {
+		if event.Repo.Name != "" && event.Repo.Name != PrivateRepositoryName {
+			counts[event.Repo.Name]++
+		}
+	}
+	ranked := make([]repositoryActivityCount, 0, len(counts))
+	for repo, count := range counts {
+		ranked = append(ranked, repositoryActivityCount{Repository: repo, Events: count})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Events != ranked[j].Events {
+			return ranked[i].Events > ranked[j].Events
+		}
+		return ranked[i].Repository < ranked[j].Repository
+	})
+	return ranked
+}
+
+// GetOrganizationActivity collects the recent activity of an organization
+// and formats it for GenerateOrganizationSummary. Events are fetched first so
+// that only the Options.MaxRepositories most active repositories are
+// processed; their activities are grouped by repository and READMEs are
+// fetched for the top few only.
+func GetOrganizationActivity(ctx context.Context, org string, maxEvents int, mode string, opts Options) (string, error) {
+	opts, err := prepareActivityOptions(ctx, mode, opts)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		log.Printf("GitHub API usage: %s", opts.RateLimiter.Stats())
+	}()
+	maxEvents = min(maxEvents, 100)
+	log.Printf("Fetching activity for organization: %s with max events: %d in mode: %s", org, maxEvents, mode)
+
+	window := opts.timeWindow()
+	repoDecisions := make(map[string]repoDecision)
+	var events []Event
+	const maxPagesAllowed = 4 // GitHub limits the number of pages to 3 for this endpoint.
+	for page := 1; page < maxPagesAllowed; page++ {
+		pageEvents, diagnostics, err := GetOrganizationEvents(ctx, org, maxEvents, page, opts)
+		if errors.Is(err, ErrGitHubRateLimited) && len(events) > 0 {
+			log.Printf("Rate limited, summarizing the %d events fetched so far: %v", len(events), err)
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		for _, diagnostic := range diagnostics {
+			log.Printf("Event diagnostic: %s", diagnostic)
+		}
+		fetched := len(pageEvents)
+		pageEvents, reachedSince := filterEventsByWindow(pageEvents, window)
+		events = append(events, filterRepositories(ctx, pageEvents, opts, repoDecisions)...)
+		if reachedSince || fetched < maxEvents {
+			break
+		}
+	}
+
+	maxRepositories := opts.MaxRepositories
+	if maxRepositories <= 0 {
+		maxRepositories = DefaultMaxRepositories
+	}
+	ranked := mostActiveRepositories(events)
+	top, rest := ranked, []repositoryActivityCount(nil)
+	if len(ranked) > maxRepositories {
+		top, rest = ranked[:maxRepositories], ranked[maxRepositories:]
+	}
+	selected := make(map[string]struct{}, len(top))
+	for _, repo := range top {
+		selected[repo.Repository] = struct{}{}
+	}
+	topEvents := make([]Event, 0, len(events))
+	for _, event := range events {
+		if _, ok := selected[event.Repo.Name]; ok {
+			topEvents = append(topEvents, event)
+		}
+	}
+
+	activities := []Activity{}
+	repositories := make(map[string]struct{})
+	commitSummariesCount := 0
+	const maxCommitSummary = 10
+	processMode := degradeModeForBudget(topEvents, mode, maxCommitSummary, repositories, opts.RepoFilter, repoDecisions, opts.RateLimiter)
+	ProcessActivities(ctx, topEvents, maxEvents, processMode, maxCommitSummary, &activities, &repositories, &commitSummariesCount, opts)
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	recentActivities := fmt.Sprintf("Recent activities in organization %s:\n", org)
+	if !window.IsZero() {
+		recentActivities += window.String() + ".\n"
+	}
+	recentActivities += "Most active repositories:"
+	for i, repo := range top {
+		separator := ","
+		if i == 0 {
+			separator = ""
+		}
+		recentActivities += fmt.Sprintf("%s %s (%d events)", separator, repo.Repository, repo.Events)
+	}
+	recentActivities += "\n"
+	if len(rest) > 0 {
+		otherEvents := 0
+		for _, repo := range rest {
+			otherEvents += repo.Events
+		}
+		recentActivities += fmt.Sprintf("%d other repositories had %d events.\n", len(rest), otherEvents)
+	}
+
+	readmeRepositories := make(map[string]struct{})
+	for _, repo := range top[:min(len(top), maxOrgReadmes)] {
+		if _, processed := repositories[repo.Repository]; processed {
+			readmeRepositories[repo.Repository] = struct{}{}
+		}
+	}
+	descriptions, err := repositoryDescriptions(ctx, readmeRepositories, opts)
+	if err != nil {
+		return "", err
+	}
+	recentActivities += descriptions
+
+	for _, repo := range top {
+		var repoActivities []Activity
+		for _, activity := range activities {
+			if activity.Repository == repo.Repository {
+				repoActivities = append(repoActivities, activity)
+			}
+		}
+		if len(repoActivities) == 0 {
+			continue
+		}
+		recentActivities += fmt.Sprintf("Activities in %s:\n", repo.Repository)
+		recentActivities += formatActivities(repoActivities, true)
+	}
+	log.Printf("Organization: %s", org)
+	log.Printf("Recent activities:\n %s", recentActivities)
+	return recentActivities, nil
+}
@@ -0,0 +1,64 @@
+package ghsummary
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGetOrganizationActivityGroupsMostActiveRepositories(t *testing.T) {
+	issue := func(id, actor, repo string) string {
+		return fmt.Sprintf(`{"id": %q, "type": "IssuesEvent", "public": true, "actor": {"login": %q}, "repo": {"name": %q},
+			"payload": {"action": "opened", "issue": {"number": %s, "title": "Issue %s in %s"}}}`, id, actor, repo, id, id, repo)
+	}
+	events := []string{
+		issue("1", "alice", "acme/web"),
+		issue("2", "bob", "acme/api"),
+		issue("3", "alice", "acme/api"),
+		issue("4", "carol", "acme/docs"),
+		issue("5", "bob", "acme/api"),
+		issue("6", "dave", "acme/web"),
+	}
+	readme := base64.StdEncoding.EncodeToString([]byte("The public API."))
+	server := newFakeGitHubServer(t, map[string]string{
+		"/orgs/acme/events?per_page=100&page=1": "[" + strings.Join(events, ",") + "]",
+		"/repos/acme/api/contents/README.md":    fmt.Sprintf(`{"encoding": "base64", "content": %q}`, readme),
+	})
+
+	opts := Options{GitHubAPIURL: server.URL, MaxRepositories: 2}
+	activity, err := GetOrganizationActivity(context.Background(), "acme", 100, "fast", opts)
+	if err != nil {
+		t.Fatalf("GetOrganizationActivity failed: %v", err)
+	}
+	for _, want := range []string{
+		"Most active repositories: acme/api (3 events), acme/web (2 events)\n",
+		"1 other repositories had 1 events.",
+		"acme/api repository description:\nThe public API.",
+		"Activities in acme/api:\nType: IssuesEvent\nBy: bob",
+		"Activities in acme/web:\nType: IssuesEvent\nBy: alice",
+	} {
+		if !strings.Contains(activity, want) {
+			t.Errorf("expected %q in activity:\n%s", want, activity)
+		}
+	}
+	if strings.Contains(activity, "Issue 4") {
+		t.Errorf("expected activities of less active repositories to be left out:\n%s", activity)
+	}
+	if strings.Index(activity, "Activities in acme/api") > strings.Index(activity, "Activities in acme/web") {
+		t.Errorf("expected the most active repository first:\n%s", activity)
+	}
+}
+
+func TestMostActiveRepositoriesSkipsAnonymizedEvents(t *testing.T) {
+	events := []Event{
+		{Repo: EventRepo{Name: PrivateRepositoryName}},
+		{Repo: EventRepo{Name: PrivateRepositoryName}},
+		{Repo: EventRepo{Name: "acme/api"}},
+	}
+	ranked := mostActiveRepositories(events)
+	if len(ranked) != 1 || ranked[0] != (repositoryActivityCount{Repository: "acme/api", Events: 1}) {
+		t.Errorf("expected only acme/api to be ranked, got %+v", ranked)
+	}
+}
@@ -0,0 +1,38 @@
+package ghsummary
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultConcurrency is the number of GitHub and LLM requests made in
+// parallel when Options.Concurrency is not set. It is kept low because
+// GitHub's secondary rate limits penalize bursts of concurrent requests.
+const DefaultConcurrency = 4
+
+// runConcurrently calls fn for every index in [0, n) on at most limit
+// goroutines and waits for all calls to finish. Callers store results by
+// index, so the order of the results does not depend on scheduling. No new
+// calls are started once ctx is done.
+func runConcurrently(ctx context.Context, n, limit int, fn func(i int)) {
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
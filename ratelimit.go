@@ -0,0 +1,293 @@
+package ghsummary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrGitHubRateLimited is returned for GitHub requests that hit the rate
+// limit when waiting for the reset would take longer than allowed.
+var ErrGitHubRateLimited = errors.New("GitHub rate limit exceeded")
+
+// RateLimitStats is a snapshot of the GitHub API budget as seen by a RateLimiter.
+type RateLimitStats struct {
+	// Calls is the number of requests sent to GitHub.
+	Calls int
+	// Known is false until a response with rate limit headers was seen.
+	Known     bool
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+func (s RateLimitStats) String() string {
+	if !s.Known {
+		return fmt.Sprintf("%d GitHub API calls used (rate limit unknown)", s.Calls)
+	}
+	return fmt.Sprintf("%d GitHub API calls used, %d of %d remaining, resets at %s",
+		s.Calls, s.Remaining, s.Limit, s.Reset.Format(time.TimeOnly))
+}
+
+// RateLimiter tracks GitHub's X-RateLimit-* headers across requests. When
+// the budget is exhausted it waits for the reset if that happens within
+// MaxWait, and fails fast with ErrGitHubRateLimited otherwise.
+type RateLimiter struct {
+	// MaxWait is the longest time a request is delayed waiting for a reset.
+	MaxWait time.Duration
+
+	mu    sync.Mutex
+	stats RateLimitStats
+}
+
+// NewRateLimiter creates a RateLimiter that waits at most one minute.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{MaxWait: time.Minute}
+}
+
+// Stats returns the current budget and the number of calls made so far.
+func (l *RateLimiter) Stats() RateLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// Remaining returns the remaining budget and whether it is known.
+func (l *RateLimiter) Remaining() (int, bool) {
+	stats := l.Stats()
+	return stats.Remaining, stats.Known
+}
+
+// update records the rate limit headers of a response.
+func (l *RateLimiter) update(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	resetAt := time.Unix(reset, 0)
+	if l.stats.Known && resetAt.Equal(l.stats.Reset) && remaining > l.stats.Remaining {
+		// A concurrent request answered earlier; keep the lower budget that
+		// already accounts for requests in flight.
+		return
+	}
+	l.stats.Known = true
+	l.stats.Remaining = remaining
+	l.stats.Limit = limit
+	l.stats.Reset = resetAt
+}
+
+// seed sets the budget from the /rate_limit endpoint without counting a call.
+func (l *RateLimiter) seed(limit, remaining int, reset time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats.Known = true
+	l.stats.Limit = limit
+	l.stats.Remaining = remaining
+	l.stats.Reset = reset
+}
+
+// acquire returns how long a request has to wait before it may be sent. When
+// it may be sent right away, one call is reserved from the remaining budget
+// so concurrent requests cannot overdraw it, and reserved is set.
+func (l *RateLimiter) acquire() (wait time.Duration, reserved bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.stats.Known {
+		return 0, false
+	}
+	if l.stats.Remaining > 0 {
+		l.stats.Remaining--
+		return 0, true
+	}
+	return time.Until(l.stats.Reset), false
+}
+
+// release gives back a call reserved by acquire that GitHub did not count.
+func (l *RateLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stats.Remaining < l.stats.Limit {
+		l.stats.Remaining++
+	}
+}
+
+// sleep waits for the given duration if it is within MaxWait.
+func (l *RateLimiter) sleep(ctx context.Context, wait time.Duration) error {
+	if wait <= 0 {
+		return nil
+	}
+	if wait > l.MaxWait {
+		return fmt.Errorf("%w: resets in %v", ErrGitHubRateLimited, wait.Round(time.Second))
+	}
+	log.Printf("GitHub rate limit reached, waiting %v", wait.Round(time.Second))
+	return sleepContext(ctx, wait)
+}
+
+// rateLimitTransport applies a RateLimiter to every request.
+type rateLimitTransport struct {
+	next    http.RoundTripper
+	limiter *RateLimiter
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	wait, reserved := t.limiter.acquire()
+	if err := t.limiter.sleep(req.Context(), wait); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.send(req, reserved)
+	if err != nil {
+		return nil, err
+	}
+
+	wait, limited := rateLimitedWait(resp)
+	if !limited {
+		return resp, nil
+	}
+	// Retry once after waiting; GitHub GET requests have no body to replay.
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if err := t.limiter.sleep(req.Context(), wait); err != nil {
+		return nil, err
+	}
+	return t.send(req, false)
+}
+
+// send sends the request and counts it, unless GitHub answered a conditional
+// request with 304 Not Modified, which does not count against the limit; the
+// reserved call is then given back.
+func (t *rateLimitTransport) send(req *http.Request, reserved bool) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusNotModified {
+		if reserved {
+			t.limiter.release()
+		}
+		t.limiter.update(resp.Header)
+		return resp, nil
+	}
+	t.limiter.mu.Lock()
+	t.limiter.stats.Calls++
+	t.limiter.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	t.limiter.update(resp.Header)
+	return resp, nil
+}
+
+// rateLimitedWait reports whether the response is a primary or secondary
+// rate limit rejection and how long GitHub asks to wait.
+func rateLimitedWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(retryAfter) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err == nil {
+			return time.Until(time.Unix(reset, 0)), true
+		}
+	}
+	return 0, resp.StatusCode == http.StatusTooManyRequests
+}
+
+type rateLimitResponse struct {
+	Resources struct {
+		Core struct {
+			Limit     int   `json:"limit"`
+			Remaining int   `json:"remaining"`
+			Reset     int64 `json:"reset"`
+		} `json:"core"`
+	} `json:"resources"`
+}
+
+// GetRateLimit seeds the limiter with the current core API budget. The
+// /rate_limit endpoint does not count against the limit.
+func GetRateLimit(ctx context.Context, limiter *RateLimiter, opts Options) error {
+	url := fmt.Sprintf("%s/rate_limit", opts.githubAPIURL())
+	req, err := newGitHubRequest(ctx, url)
+	if err != nil {
+		return err
+	}
+	opts.RateLimiter = nil
+	opts.HTTPCache = nil
+	resp, err := opts.githubTransport().RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch rate limit: %s", resp.Status)
+	}
+	var rateLimit rateLimitResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rateLimit); err != nil {
+		return fmt.Errorf("error decoding JSON response: %w", err)
+	}
+	core := rateLimit.Resources.Core
+	limiter.seed(core.Limit, core.Remaining, time.Unix(core.Reset, 0))
+	return nil
+}
+
+// EstimateAPICalls estimates how many additional GitHub requests processing
+// the events will need: one compare call per push, commit detail calls in
+// strict mode, pull request details missing from trimmed payloads, one
+// README call per repository not in knownRepos and, when filter needs
+// repository metadata, one metadata call per repository not in checkedRepos.
+// Events of repositories the filter excludes by name are not counted.
+func EstimateAPICalls(events []Event, mode string, commitSummaries int, knownRepos map[string]struct{}, filter RepoFilter, checkedRepos map[string]struct{}) int {
+	calls := 0
+	repos := make(map[string]struct{})
+	metadata := make(map[string]struct{})
+	for _, event := range events {
+		repo := event.Repo.Name
+		if repo != "" && !filter.allowsName(repo) {
+			continue
+		}
+		switch payload := event.Payload.(type) {
+		case *PushEventPayload:
+			if payload.Head != "" && payload.Before != "" {
+				calls++
+			}
+		case *PullRequestEventPayload:
+			if pullRequestActions[payload.Action] && payload.PullRequest.Title == "" {
+				calls++
+			}
+		case *PullRequestReviewEventPayload:
+			if payload.PullRequest.Title == "" {
+				calls++
+			}
+		case *PullRequestReviewCommentEventPayload:
+			if payload.PullRequest.Title == "" {
+				calls++
+			}
+		}
+		if repo == "" || repo == PrivateRepositoryName {
+			continue
+		}
+		if _, known := knownRepos[repo]; !known {
+			repos[repo] = struct{}{}
+		}
+		if _, checked := checkedRepos[repo]; !checked && filter.needsMetadata() {
+			metadata[repo] = struct{}{}
+		}
+	}
+	if isStrictMode(mode) {
+		calls += commitSummaries
+	}
+	return calls + len(repos) + len(metadata)
+}
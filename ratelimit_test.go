@@ -0,0 +1,111 @@
+package ghsummary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterTracksBudgetAndRetries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Hour).Unix()))
+		if requests == 1 {
+			// Secondary rate limit asking to retry right away.
+			w.Header().Set("X-RateLimit-Remaining", "10")
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		fmt.Fprint(w, `[]`)
+	}))
+	t.Cleanup(server.Close)
+
+	limiter := NewRateLimiter()
+	opts := Options{GitHubAPIURL: server.URL, RateLimiter: limiter}
+
+	events, _, err := GetEvents(context.Background(), "octo", 10, 1, opts)
+	if err != nil {
+		t.Fatalf("GetEvents failed: %v", err)
+	}
+	if len(events) != 0 || requests != 2 {
+		t.Fatalf("expected a retry after the 429, got %d requests", requests)
+	}
+
+	stats := limiter.Stats()
+	if stats.Calls != 2 || !stats.Known || stats.Remaining != 0 || stats.Limit != 60 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	// The budget is exhausted and resets in an hour, so the next request fails
+	// without reaching the server.
+	_, _, err = GetEvents(context.Background(), "octo", 10, 2, opts)
+	if !errors.Is(err, ErrGitHubRateLimited) {
+		t.Fatalf("expected ErrGitHubRateLimited, got %v", err)
+	}
+	if requests != 2 {
+		t.Fatalf("expected no request to be sent, got %d requests", requests)
+	}
+	if !strings.Contains(limiter.Stats().String(), "2 GitHub API calls used, 0 of 60 remaining") {
+		t.Errorf("unexpected report: %s", limiter.Stats())
+	}
+}
+
+func TestGetRateLimitSeedsLimiter(t *testing.T) {
+	server := newFakeGitHubServer(t, map[string]string{
+		"/rate_limit": `{"resources": {"core": {"limit": 5000, "remaining": 4321, "reset": 1790000000}}}`,
+	})
+
+	limiter := NewRateLimiter()
+	if err := GetRateLimit(context.Background(), limiter, Options{GitHubAPIURL: server.URL, RateLimiter: limiter}); err != nil {
+		t.Fatalf("GetRateLimit failed: %v", err)
+	}
+	stats := limiter.Stats()
+	if stats.Calls != 0 || stats.Remaining != 4321 || stats.Limit != 5000 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestEstimateAPICalls(t *testing.T) {
+	events := []Event{
+		{Repo: EventRepo{Name: "octo/a"}, Payload: &PushEventPayload{Before: "1", Head: "2"}},
+		{Repo: EventRepo{Name: "octo/a"}, Payload: &PushEventPayload{Before: "2", Head: "3"}},
+		{Repo: EventRepo{Name: "octo/b"}, Payload: &PullRequestEventPayload{Action: "opened"}},
+		{Repo: EventRepo{Name: "octo/c"}, Payload: &PullRequestEventPayload{Action: "labeled"}},
+		{Repo: EventRepo{Name: "octo/known"}, Payload: &WatchEventPayload{}},
+	}
+	known := map[string]struct{}{"octo/known": {}}
+
+	// 2 compares + 1 pull request + 3 READMEs
+	if got := EstimateAPICalls(events, "fast", 10, known, RepoFilter{}, nil); got != 6 {
+		t.Errorf("expected 6 calls in fast mode, got %d", got)
+	}
+	if got := EstimateAPICalls(events, "strict", 10, known, RepoFilter{}, nil); got != 16 {
+		t.Errorf("expected 16 calls in strict mode, got %d", got)
+	}
+
+	events = append(events,
+		Event{Repo: EventRepo{Name: "octo/d"}, Payload: &PullRequestReviewEventPayload{}},
+		Event{Repo: EventRepo{Name: "octo/d"}, Payload: &PullRequestReviewCommentEventPayload{}},
+		Event{Repo: EventRepo{Name: "octo/d"}, Payload: &PullRequestReviewEventPayload{PullRequest: PullRequest{Title: "Complete"}}},
+		Event{Repo: EventRepo{Name: "other/e"}, Payload: &PushEventPayload{Before: "1", Head: "2"}},
+	)
+	// 2 more pull requests + 1 more compare + 2 more READMEs
+	if got := EstimateAPICalls(events, "fast", 10, known, RepoFilter{}, nil); got != 11 {
+		t.Errorf("expected 11 calls with reviews, got %d", got)
+	}
+	// other/e is excluded; metadata is fetched for octo/a, octo/c, octo/d
+	// and octo/known, as octo/b was already checked.
+	filter := RepoFilter{SkipForks: true, ExcludeRepos: []string{"other/*"}}
+	if got := EstimateAPICalls(events, "fast", 10, known, filter, map[string]struct{}{"octo/b": {}}); got != 13 {
+		t.Errorf("expected 13 calls with repository metadata, got %d", got)
+	}
+}
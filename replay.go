@@ -0,0 +1,202 @@
+package ghsummary
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrNoRecordedResponse is returned when replaying a request that was not
+// recorded.
+var ErrNoRecordedResponse = errors.New("no recorded response")
+
+// HTTPFixtures is a directory of recorded GitHub responses, one JSON file
+// per request. Options.Record saves every response into it and
+// Options.Replay serves them back without any network access, so a summary
+// can be reproduced from the exact inputs that produced it.
+//
+// Fixtures are named after the request path and query, without the host
+// and the path of the API root (e.g. /api/v3 on GitHub Enterprise Server),
+// so a recording made against github.com replays against any API URL.
+// Request headers, including the token, are not recorded.
+type HTTPFixtures struct {
+	Dir string
+}
+
+// httpFixture is the on-disk representation of a recorded response.
+type httpFixture struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	// Body is kept as text when it is valid JSON, which keeps fixtures
+	// readable and editable, and base64 encoded otherwise.
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodyData []byte          `json:"body_data,omitempty"`
+}
+
+// NewHTTPFixtures creates the fixture directory if needed.
+func NewHTTPFixtures(dir string) (*HTTPFixtures, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating fixture directory: %w", err)
+	}
+	return &HTTPFixtures{Dir: dir}, nil
+}
+
+var fixtureNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fixtureURI returns the path and query of a request relative to the first
+// of the API root paths it is under.
+func fixtureURI(req *http.Request, apiPaths []string) string {
+	uri := req.URL.RequestURI()
+	for _, prefix := range apiPaths {
+		if prefix != "" && strings.HasPrefix(uri, prefix+"/") {
+			return strings.TrimPrefix(uri, prefix)
+		}
+	}
+	return uri
+}
+
+// fixtureName names the fixture of a request after its path and query
+// relative to the API root, with a hash of the method, path, query and body
+// to keep names unique.
+func fixtureName(req *http.Request, body []byte, apiPaths []string) string {
+	uri := fixtureURI(req, apiPaths)
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n", req.Method, uri)
+	hash.Write(body)
+	readable := strings.Trim(fixtureNameUnsafe.ReplaceAllString(uri, "_"), "_")
+	if len(readable) > 100 {
+		readable = readable[:100]
+	}
+	return fmt.Sprintf("%s-%s.json", readable, hex.EncodeToString(hash.Sum(nil))[:12])
+}
+
+// requestBody reads the body of a request and restores it for sending.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func (f *HTTPFixtures) save(req *http.Request, name string, resp *http.Response, body []byte) error {
+	// The body is re-indented, so its recorded length would be misleading.
+	header := resp.Header.Clone()
+	header.Del("Content-Length")
+	fixture := httpFixture{Method: req.Method, URL: req.URL.String(), StatusCode: resp.StatusCode, Header: header}
+	if json.Valid(body) {
+		fixture.Body = body
+	} else {
+		fixture.BodyData = body
+	}
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(fixture); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(f.Dir, name), data.Bytes())
+}
+
+func (f *HTTPFixtures) load(name string) (*httpFixture, error) {
+	data, err := os.ReadFile(filepath.Join(f.Dir, name))
+	if err != nil {
+		return nil, err
+	}
+	var fixture httpFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("decoding fixture %s: %w", name, err)
+	}
+	return &fixture, nil
+}
+
+// recordTransport saves every response of next into the fixtures. apiPaths
+// are the paths of the REST and GraphQL API roots, left out of fixture names.
+type recordTransport struct {
+	next     http.RoundTripper
+	fixtures *HTTPFixtures
+	apiPaths []string
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	name := fixtureName(req, reqBody, t.apiPaths)
+	if err := t.fixtures.save(req, name, resp, body); err != nil {
+		log.Printf("Error recording %s: %v", req.URL, err)
+	} else {
+		log.Printf("Recorded %s as %s", req.URL, name)
+	}
+	return resp, nil
+}
+
+// replayTransport serves recorded responses and never sends a request.
+type replayTransport struct {
+	fixtures *HTTPFixtures
+	apiPaths []string
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	name := fixtureName(req, reqBody, t.apiPaths)
+	fixture, err := t.fixtures.load(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s (%s)", ErrNoRecordedResponse, req.Method, req.URL.RequestURI(), name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	body := []byte(fixture.Body)
+	if fixture.BodyData != nil {
+		body = fixture.BodyData
+	}
+	header := fixture.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.StatusCode, http.StatusText(fixture.StatusCode)),
+		StatusCode:    fixture.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
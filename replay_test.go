@@ -0,0 +1,140 @@
+package ghsummary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// replayOctoFixtures replays the activity of the user octo recorded in
+// testdata/replay/octo.
+func replayOctoFixtures() Options {
+	return Options{Replay: &HTTPFixtures{Dir: "testdata/replay/octo"}}
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.URL.Path == "/repos/octo/app/contents/README.md" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"login": "octo", "bio": "<b>Go</b> & tools"}`)
+	}))
+	fixtures, err := NewHTTPFixtures(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(opts Options, url string) (int, string, error) {
+		resp, err := makeGitHubRequest(context.Background(), url, opts)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), err
+	}
+	record := Options{Record: fixtures}
+	if status, _, err := get(record, server.URL+"/users/octo"); err != nil || status != http.StatusOK {
+		t.Fatalf("recording failed: %d, %v", status, err)
+	}
+	if status, _, err := get(record, server.URL+"/repos/octo/app/contents/README.md"); err != nil || status != http.StatusNotFound {
+		t.Fatalf("recording failed: %d, %v", status, err)
+	}
+	server.Close()
+
+	// Fixtures do not depend on the host, and replay never uses the network.
+	replay := Options{Replay: fixtures}
+	status, body, err := get(replay, "https://api.github.com/users/octo")
+	if err != nil || status != http.StatusOK || !strings.Contains(body, `"<b>Go</b> & tools"`) {
+		t.Fatalf("unexpected replay: %d %q, %v", status, body, err)
+	}
+	if status, _, err := get(replay, "https://api.github.com/repos/octo/app/contents/README.md"); err != nil || status != http.StatusNotFound {
+		t.Fatalf("expected the recorded 404, got %d, %v", status, err)
+	}
+	if _, _, err := get(replay, "https://api.github.com/users/other"); !errors.Is(err, ErrNoRecordedResponse) {
+		t.Fatalf("expected ErrNoRecordedResponse, got %v", err)
+	}
+}
+
+func TestReplayIgnoresAPIRootPath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login": "octo"}`)
+	}))
+	defer server.Close()
+	fixtures, err := NewHTTPFixtures(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A recording made against GitHub Enterprise Server replays against github.com.
+	record := Options{Record: fixtures, GitHubAPIURL: server.URL + "/api/v3"}
+	resp, err := makeGitHubRequest(context.Background(), server.URL+"/api/v3/users/octo", record)
+	if err != nil {
+		t.Fatalf("recording failed: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = makeGitHubRequest(context.Background(), "https://api.github.com/users/octo", Options{Replay: fixtures})
+	if err != nil {
+		t.Fatalf("expected the recording to replay, got %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestGetUserActivityReplaysFixtures(t *testing.T) {
+	activity, err := GetUserActivity(context.Background(), "octo", 100, "fast", replayOctoFixtures())
+	if err != nil {
+		t.Fatalf("GetUserActivity failed: %v", err)
+	}
+	for _, want := range []string{
+		"octo/app repository description:\n# app\n\nA command-line tool that turns GitHub activity into a short summary.",
+		"Type: PushEvent\nRepository: octo/app\nContent: Add summary cache\nPrune stale summaries\n",
+		"Content: Pull request merged: #12 Cache commit summaries\nChanges: +120 -8 in 4 files, 3 commits\n",
+		"Repository: acme/lib\nContent: Commented on issue #7: Panic on empty input (open)\nOpened by: someone\n",
+		"Type: CreateEvent\nRepository: octo/app\n",
+	} {
+		if !strings.Contains(activity, want) {
+			t.Errorf("expected %q in activity:\n%s", want, activity)
+		}
+	}
+	if strings.Contains(activity, "acme/lib repository description") {
+		t.Errorf("expected no description for a repository without README:\n%s", activity)
+	}
+}
+
+func TestProcessActivitiesReplaysFixtures(t *testing.T) {
+	opts := replayOctoFixtures()
+	opts.Summarizer = &stubSummarizer{}
+	events, _, err := GetEvents(context.Background(), "octo", 100, 1, opts)
+	if err != nil {
+		t.Fatalf("GetEvents failed: %v", err)
+	}
+
+	activities := []Activity{}
+	repositories := make(map[string]struct{})
+	commitSummariesCount := 0
+	ProcessActivities(context.Background(), events, 100, "strict", 10, &activities, &repositories, &commitSummariesCount, opts)
+
+	if len(activities) != 4 || commitSummariesCount != 2 {
+		t.Fatalf("expected 4 activities and 2 commit summaries, got %d and %d: %+v", len(activities), commitSummariesCount, activities)
+	}
+	push := activities[0].Content
+	for _, want := range []string{
+		"Commit summary: commit Commit message: Add summary cache\nFile: summarycache.go\nPatch:\n@@ -0,0 +1,3 @@",
+		"Commit summary: commit Commit message: Prune stale summaries\nFile: summarycache.go\n",
+	} {
+		if !strings.Contains(push, want) {
+			t.Errorf("expected %q in push content:\n%s", want, push)
+		}
+	}
+	if _, ok := repositories["acme/lib"]; !ok || len(repositories) != 2 {
+		t.Errorf("unexpected repositories: %v", repositories)
+	}
+}
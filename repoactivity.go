@@ -0,0 +1,43 @@
+package ghsummary
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// GetRepositoryActivity collects the recent activity of a repository, given
+// as owner/name, and formats it for GenerateRepositorySummary. Each activity
+// names the user who performed it.
+func GetRepositoryActivity(ctx context.Context, repo string, maxEvents int, mode string, opts Options) (string, error) {
+	opts, err := prepareActivityOptions(ctx, mode, opts)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		log.Printf("GitHub API usage: %s", opts.RateLimiter.Stats())
+	}()
+
+	log.Printf("Fetching activity for repository: %s with max events: %d in mode: %s", repo, maxEvents, mode)
+	pager := func(ctx context.Context, perPage int, page int, opts Options) ([]Event, []EventDiagnostic, error) {
+		return GetRepositoryEvents(ctx, repo, perPage, page, opts)
+	}
+	activities, repositories, err := collectActivities(ctx, pager, maxEvents, mode, opts)
+	if err != nil {
+		return "", err
+	}
+
+	recentActivities := fmt.Sprintf("Recent activities in repository %s:\n", repo)
+	if window := opts.timeWindow(); !window.IsZero() {
+		recentActivities += window.String() + ".\n"
+	}
+	descriptions, err := repositoryDescriptions(ctx, repositories, opts)
+	if err != nil {
+		return "", err
+	}
+	recentActivities += descriptions
+	recentActivities += formatActivities(activities, true)
+	log.Printf("Repository: %s", repo)
+	log.Printf("Recent activities:\n %s", recentActivities)
+	return recentActivities, nil
+}
@@ -0,0 +1,34 @@
+package ghsummary
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGetRepositoryActivityNamesActors(t *testing.T) {
+	server := newFakeGitHubServer(t, map[string]string{
+		"/repos/octo/app/events": `[
+			{"id": "2", "type": "ReleaseEvent", "public": true, "actor": {"login": "alice"}, "repo": {"name": "octo/app"},
+			 "payload": {"action": "published", "release": {"tag_name": "v1.2.0", "name": "Faster sync"}}},
+			{"id": "1", "type": "PullRequestEvent", "public": true, "actor": {"login": "bob"}, "repo": {"name": "octo/app"},
+			 "payload": {"action": "closed", "number": 7, "pull_request": {"number": 7, "title": "Add sync", "merged": true}}}
+		]`,
+	})
+
+	activity, err := GetRepositoryActivity(context.Background(), "octo/app", 10, "fast", Options{GitHubAPIURL: server.URL})
+	if err != nil {
+		t.Fatalf("GetRepositoryActivity failed: %v", err)
+	}
+	for _, want := range []string{
+		"Recent activities in repository octo/app:",
+		"Type: ReleaseEvent\nBy: alice\nRepository: octo/app",
+		"Type: PullRequestEvent\nBy: bob\nRepository: octo/app",
+		"v1.2.0",
+		"Add sync",
+	} {
+		if !strings.Contains(activity, want) {
+			t.Errorf("expected %q in activity:\n%s", want, activity)
+		}
+	}
+}
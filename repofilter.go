@@ -0,0 +1,205 @@
+package ghsummary
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+)
+
+// PrivateRepoPolicy decides how activity in private repositories, visible
+// when the token has access to them, appears in the summary.
+type PrivateRepoPolicy string
+
+const (
+	// PrivateReposExclude leaves private repositories out. This is the default.
+	PrivateReposExclude PrivateRepoPolicy = "exclude"
+	// PrivateReposAnonymize keeps the activity but replaces the repository
+	// name and content with a generic description.
+	PrivateReposAnonymize PrivateRepoPolicy = "anonymize"
+	// PrivateReposInclude treats private repositories like public ones.
+	PrivateReposInclude PrivateRepoPolicy = "include"
+)
+
+// PrivateRepositoryName replaces the name of anonymized private repositories.
+const PrivateRepositoryName = "a private repository"
+
+// ParsePrivateRepoPolicy parses the --private value. Empty means exclude.
+func ParsePrivateRepoPolicy(value string) (PrivateRepoPolicy, error) {
+	switch policy := PrivateRepoPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return PrivateReposExclude, nil
+	case PrivateReposExclude, PrivateReposAnonymize, PrivateReposInclude:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid private repository policy %q (exclude, anonymize, include)", value)
+}
+
+// RepoFilter selects the repositories whose activity is summarized. Repo
+// patterns are globs matched against "owner/name" and org patterns are
+// globs matched against the owner, e.g. "octo/*" or "acme-*".
+type RepoFilter struct {
+	// IncludeRepos and IncludeOrgs, when not empty, keep only matching
+	// repositories. A repository matching either list is kept.
+	IncludeRepos []string
+	IncludeOrgs  []string
+	// ExcludeRepos and ExcludeOrgs drop matching repositories.
+	ExcludeRepos []string
+	ExcludeOrgs  []string
+	// SkipForks, SkipArchived and SkipTopics drop repositories based on
+	// their metadata, which costs one GitHub request per repository.
+	SkipForks    bool
+	SkipArchived bool
+	SkipTopics   []string
+	// Private is the policy for private repositories. Empty means exclude.
+	Private PrivateRepoPolicy
+}
+
+// SplitPatterns splits a comma-separated list of patterns or topics.
+func SplitPatterns(list string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsName applies the include and exclude lists to "owner/name".
+func (f RepoFilter) allowsName(repo string) bool {
+	owner, _, _ := strings.Cut(repo, "/")
+	if len(f.IncludeRepos) > 0 || len(f.IncludeOrgs) > 0 {
+		if !matchAny(f.IncludeRepos, repo) && !matchAny(f.IncludeOrgs, owner) {
+			return false
+		}
+	}
+	return !matchAny(f.ExcludeRepos, repo) && !matchAny(f.ExcludeOrgs, owner)
+}
+
+func (f RepoFilter) needsMetadata() bool {
+	return f.SkipForks || f.SkipArchived || len(f.SkipTopics) > 0
+}
+
+// skipReason returns why a repository is skipped based on its metadata, or
+// an empty string if it is kept.
+func (f RepoFilter) skipReason(repo *Repository) string {
+	switch {
+	case f.SkipForks && repo.Fork:
+		return "fork"
+	case f.SkipArchived && repo.Archived:
+		return "archived"
+	}
+	for _, topic := range repo.Topics {
+		for _, skipped := range f.SkipTopics {
+			if strings.EqualFold(topic, skipped) {
+				return "topic " + topic
+			}
+		}
+	}
+	return ""
+}
+
+type repoDecision int
+
+const (
+	repoKeep repoDecision = iota
+	repoSkip
+	repoAnonymize
+)
+
+// filterRepositories applies opts.RepoFilter to the events. Decisions are
+// stored in decisions so repository metadata is fetched once per run.
+// Events of anonymized private repositories are replaced by events that
+// carry neither the repository name nor the payload.
+func filterRepositories(ctx context.Context, events []Event, opts Options, decisions map[string]repoDecision) []Event {
+	filter := opts.RepoFilter
+
+	var pending []string
+	private := make(map[string]bool)
+	for _, event := range events {
+		repo := event.Repo.Name
+		if _, decided := decisions[repo]; decided || repo == "" {
+			continue
+		}
+		if _, seen := private[repo]; !seen {
+			pending = append(pending, repo)
+		}
+		private[repo] = private[repo] || !event.Public
+	}
+
+	metadata := make([]*Repository, len(pending))
+	if filter.needsMetadata() {
+		runConcurrently(ctx, len(pending), opts.concurrency(), func(i int) {
+			if !filter.allowsName(pending[i]) {
+				return
+			}
+			repository, err := GetRepository(ctx, pending[i], opts)
+			if err != nil {
+				log.Printf("Error fetching repository %s: %v", pending[i], err)
+				return
+			}
+			metadata[i] = repository
+		})
+	}
+
+	for i, repo := range pending {
+		isPrivate := private[repo] || (metadata[i] != nil && metadata[i].Private)
+		switch {
+		case !filter.allowsName(repo):
+			log.Printf("Skipping repository %s: excluded by filter", repo)
+			decisions[repo] = repoSkip
+		case metadata[i] != nil && filter.skipReason(metadata[i]) != "":
+			log.Printf("Skipping repository %s: %s", repo, filter.skipReason(metadata[i]))
+			decisions[repo] = repoSkip
+		case isPrivate && filter.Private == PrivateReposAnonymize:
+			decisions[repo] = repoAnonymize
+		case isPrivate && filter.Private != PrivateReposInclude:
+			log.Printf("Skipping private repository")
+			decisions[repo] = repoSkip
+		default:
+			decisions[repo] = repoKeep
+		}
+	}
+
+	kept := make([]Event, 0, len(events))
+	for _, event := range events {
+		switch decisions[event.Repo.Name] {
+		case repoKeep:
+			kept = append(kept, event)
+		case repoAnonymize:
+			kept = append(kept, Event{
+				ID:        event.ID,
+				Type:      event.Type,
+				Actor:     event.Actor,
+				Repo:      EventRepo{Name: PrivateRepositoryName},
+				CreatedAt: event.CreatedAt,
+			})
+		}
+	}
+	return kept
+}
+
+// privateActivityDescriptions describe activity in anonymized private
+// repositories without any detail from the event.
+var privateActivityDescriptions = map[string]string{
+	"PushEvent":                     "Pushed commits",
+	"PullRequestEvent":              "Worked on a pull request",
+	"PullRequestReviewEvent":        "Reviewed a pull request",
+	"PullRequestReviewCommentEvent": "Commented on a pull request review",
+	"IssuesEvent":                   "Worked on an issue",
+	"IssueCommentEvent":             "Commented on an issue",
+	"ReleaseEvent":                  "Published a release",
+	"CreateEvent":                   "Created a branch or tag",
+	"DeleteEvent":                   "Deleted a branch or tag",
+}
@@ -0,0 +1,92 @@
+package ghsummary
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRepoFilterAllowsName(t *testing.T) {
+	filter := RepoFilter{
+		IncludeRepos: []string{"octo/*"},
+		IncludeOrgs:  []string{"acme-*"},
+		ExcludeRepos: []string{"octo/dotfiles"},
+		ExcludeOrgs:  []string{"acme-secret"},
+	}
+	tests := map[string]bool{
+		"octo/app":          true,
+		"Octo/App":          true,
+		"octo/dotfiles":     false,
+		"acme-labs/api":     true,
+		"acme-secret/api":   false,
+		"someone/else":      false,
+		"octocat/something": false,
+	}
+	for repo, want := range tests {
+		if got := filter.allowsName(repo); got != want {
+			t.Errorf("allowsName(%q) = %v, want %v", repo, got, want)
+		}
+	}
+	if !(RepoFilter{}).allowsName("anyone/anything") {
+		t.Error("expected an empty filter to allow every repository")
+	}
+}
+
+func TestParsePrivateRepoPolicy(t *testing.T) {
+	if policy, err := ParsePrivateRepoPolicy(""); err != nil || policy != PrivateReposExclude {
+		t.Errorf("expected exclude by default, got %q, %v", policy, err)
+	}
+	if policy, err := ParsePrivateRepoPolicy("Anonymize"); err != nil || policy != PrivateReposAnonymize {
+		t.Errorf("expected anonymize, got %q, %v", policy, err)
+	}
+	if _, err := ParsePrivateRepoPolicy("show"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+func TestGetUserActivityAppliesRepoFilter(t *testing.T) {
+	server := newFakeGitHubServer(t, map[string]string{
+		"/users/octo/events": `[
+			{"id": "1", "type": "IssuesEvent", "public": true, "repo": {"name": "octo/app"},
+			 "payload": {"action": "opened", "issue": {"number": 1, "title": "Public issue"}}},
+			{"id": "2", "type": "IssuesEvent", "public": true, "repo": {"name": "octo/fork"},
+			 "payload": {"action": "opened", "issue": {"number": 2, "title": "Fork issue"}}},
+			{"id": "3", "type": "IssuesEvent", "public": true, "repo": {"name": "octo/homework"},
+			 "payload": {"action": "opened", "issue": {"number": 3, "title": "Homework issue"}}},
+			{"id": "4", "type": "IssuesEvent", "public": false, "repo": {"name": "octo/secret-plan"},
+			 "payload": {"action": "opened", "issue": {"number": 4, "title": "Secret issue"}}}
+		]`,
+		"/repos/octo/app":         `{"full_name": "octo/app"}`,
+		"/repos/octo/fork":        `{"full_name": "octo/fork", "fork": true}`,
+		"/repos/octo/homework":    `{"full_name": "octo/homework", "topics": ["school"]}`,
+		"/repos/octo/secret-plan": `{"full_name": "octo/secret-plan", "private": true}`,
+	})
+
+	filter := RepoFilter{SkipForks: true, SkipTopics: []string{"School"}}
+	for _, tt := range []struct {
+		policy  PrivateRepoPolicy
+		want    []string
+		notWant []string
+	}{
+		{policy: "", want: []string{"Public issue"}, notWant: []string{"Fork issue", "Homework issue", "secret", "Secret", PrivateRepositoryName}},
+		{policy: PrivateReposAnonymize, want: []string{"Public issue", "Repository: a private repository\nContent: Worked on an issue"}, notWant: []string{"secret", "Secret"}},
+		{policy: PrivateReposInclude, want: []string{"Public issue", "Secret issue"}},
+	} {
+		filter.Private = tt.policy
+		opts := Options{GitHubAPIURL: server.URL, RepoFilter: filter}
+		activity, err := GetUserActivity(context.Background(), "octo", 100, "fast", opts)
+		if err != nil {
+			t.Fatalf("GetUserActivity failed: %v", err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(activity, want) {
+				t.Errorf("policy %q: expected %q in activity:\n%s", tt.policy, want, activity)
+			}
+		}
+		for _, notWant := range tt.notWant {
+			if strings.Contains(activity, notWant) {
+				t.Errorf("policy %q: unexpected %q in activity:\n%s", tt.policy, notWant, activity)
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package ghsummary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// ActivitySource produces the activities of a user from one place, such as
+// the GitHub events feed or a local git repository. GetSourcesActivity
+// combines several sources into one summary prompt.
+type ActivitySource interface {
+	// Name identifies the source in logs.
+	Name() string
+	// Activities returns up to maxEvents activities within the time window
+	// of opts, newest first. In strict mode commits are summarized with
+	// opts.Summarizer.
+	Activities(ctx context.Context, maxEvents int, mode string, opts Options) ([]Activity, error)
+}
+
+// RepositoryDescriber is implemented by sources that can describe the
+// repositories of their activities, e.g. with their READMEs.
+type RepositoryDescriber interface {
+	// DescribeRepositories returns descriptions by repository name.
+	// Repositories without a description are left out.
+	DescribeRepositories(ctx context.Context, repositories []string, opts Options) (map[string]string, error)
+}
+
+// GitHubSource is the public events feed of a GitHub user.
+type GitHubSource struct {
+	Username string
+}
+
+func (s GitHubSource) Name() string {
+	return "GitHub user " + s.Username
+}
+
+func (s GitHubSource) Activities(ctx context.Context, maxEvents int, mode string, opts Options) ([]Activity, error) {
+	activities, _, err := collectUserActivities(ctx, s.Username, maxEvents, mode, opts)
+	return activities, err
+}
+
+// DescribeRepositories uses the READMEs of the GitHub repositories.
+func (s GitHubSource) DescribeRepositories(ctx context.Context, repositories []string, opts Options) (map[string]string, error) {
+	return githubReadmes(ctx, repositories, opts)
+}
+
+// GetSourcesActivity collects the activity of a user from several sources
+// concurrently and formats it like GetUserActivity. Activities of different
+// sources are merged newest first and capped at maxEvents. Sources that fail
+// are left out; an error is returned only if every source failed.
+func GetSourcesActivity(ctx context.Context, username string, sources []ActivitySource, maxEvents int, mode string, opts Options) (string, error) {
+	if len(sources) == 0 {
+		return "", errors.New("no activity sources")
+	}
+	if usesGitHub(sources) {
+		var err error
+		if opts, err = prepareActivityOptions(ctx, mode, opts); err != nil {
+			return "", err
+		}
+		defer func() {
+			log.Printf("GitHub API usage: %s", opts.RateLimiter.Stats())
+		}()
+	} else if isStrictMode(mode) {
+		var err error
+		if opts, err = opts.withSummarizer(); err != nil {
+			return "", err
+		}
+	}
+
+	// Sources are read in parallel; split the concurrency between them so
+	// the total number of requests in flight stays bounded.
+	workers := min(opts.concurrency(), len(sources))
+	sourceOpts := opts
+	sourceOpts.Concurrency = max(1, opts.concurrency()/workers)
+
+	results := make([][]Activity, len(sources))
+	errs := make([]error, len(sources))
+	runConcurrently(ctx, len(sources), workers, func(i int) {
+		results[i], errs[i] = sources[i].Activities(ctx, maxEvents, mode, sourceOpts)
+	})
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	fetched := 0
+	for i, source := range sources {
+		if errs[i] != nil {
+			log.Printf("Error fetching activity from %s: %v", source.Name(), errs[i])
+			continue
+		}
+		fetched++
+	}
+	if fetched == 0 {
+		return "", errors.Join(errs...)
+	}
+
+	descriptions := make(map[string]string)
+	for i, source := range sources {
+		describer, ok := source.(RepositoryDescriber)
+		if !ok || errs[i] != nil {
+			continue
+		}
+		described, err := describer.DescribeRepositories(ctx, activityRepositories(results[i]), opts)
+		if err != nil {
+			return "", err
+		}
+		for repo, description := range described {
+			if _, exists := descriptions[repo]; !exists {
+				descriptions[repo] = description
+			}
+		}
+	}
+
+	recentActivities := fmt.Sprintf("Recent activities for user %s:\n", username)
+	if window := opts.timeWindow(); !window.IsZero() {
+		recentActivities += window.String() + ".\n"
+	}
+	recentActivities += formatRepositoryDescriptions(descriptions)
+	recentActivities += formatActivities(mergeActivities(results, maxEvents), false)
+	log.Printf("User: %s", username)
+	log.Printf("Recent activities:\n %s", recentActivities)
+	return recentActivities, nil
+}
+
+// usesGitHub reports whether any of the sources makes GitHub requests, which
+// need a rate limiter seeded with the current budget.
+func usesGitHub(sources []ActivitySource) bool {
+	for _, source := range sources {
+		if _, ok := source.(GitHubSource); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeActivities combines the activities of several sources newest first,
+// keeping at most maxEvents. The activities of a single source are kept in
+// their order.
+func mergeActivities(results [][]Activity, maxEvents int) []Activity {
+	if len(results) == 1 {
+		return results[0]
+	}
+	var merged []Activity
+	for _, activities := range results {
+		merged = append(merged, activities...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.After(merged[j].CreatedAt)
+	})
+	if maxEvents > 0 && len(merged) > maxEvents {
+		merged = merged[:maxEvents]
+	}
+	return merged
+}
+
+// activityRepositories returns the sorted names of the repositories of the
+// activities, leaving out anonymized private repositories.
+func activityRepositories(activities []Activity) []string {
+	seen := make(map[string]bool)
+	var repositories []string
+	for _, activity := range activities {
+		repo := activity.Repository
+		if repo == "" || repo == PrivateRepositoryName || seen[repo] {
+			continue
+		}
+		seen[repo] = true
+		repositories = append(repositories, repo)
+	}
+	sort.Strings(repositories)
+	return repositories
+}
@@ -0,0 +1,125 @@
+package ghsummary
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SummaryCache stores LLM commit summaries on disk, so commits summarized in
+// an earlier run are not sent to the LLM again.
+type SummaryCache struct {
+	Dir string
+}
+
+// SummaryCacheKey identifies a commit summary. A summary is only reused for
+// the same commit, model and commit prompt.
+type SummaryCacheKey struct {
+	Repo       string
+	SHA        string
+	Model      string
+	PromptHash string
+}
+
+type summaryCacheEntry struct {
+	SummaryCacheKey
+	Summary  string    `json:"summary"`
+	StoredAt time.Time `json:"stored_at"`
+}
+
+// NewSummaryCache creates the cache directory if needed.
+func NewSummaryCache(dir string) (*SummaryCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating summary cache directory: %w", err)
+	}
+	return &SummaryCache{Dir: dir}, nil
+}
+
+// commitPromptHash identifies the current commit summary prompt.
+func commitPromptHash() string {
+	hash := sha256.Sum256([]byte(SystemPromptSummaryCommit))
+	return hex.EncodeToString(hash[:8])
+}
+
+// commitSummaryKey builds the cache key of a commit summarized by summarizer.
+func commitSummaryKey(repo, sha string, summarizer Summarizer) SummaryCacheKey {
+	model := fmt.Sprintf("%T", summarizer)
+	if namer, ok := summarizer.(CommitModelNamer); ok {
+		model = namer.CommitModelName()
+	}
+	return SummaryCacheKey{Repo: repo, SHA: sha, Model: model, PromptHash: commitPromptHash()}
+}
+
+func (c *SummaryCache) path(key SummaryCacheKey) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n%s", key.Repo, key.SHA, key.Model, key.PromptHash)
+	return filepath.Join(c.Dir, hex.EncodeToString(hash.Sum(nil))+".json")
+}
+
+// Get returns the cached summary for key, if any. A hit refreshes the entry
+// so Prune only drops summaries that are no longer used.
+func (c *SummaryCache) Get(key SummaryCacheKey) (string, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	var entry summaryCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.SummaryCacheKey != key {
+		return "", false
+	}
+	touchFile(path)
+	return entry.Summary, true
+}
+
+// Put stores the summary for key.
+func (c *SummaryCache) Put(key SummaryCacheKey, summary string) error {
+	data, err := json.Marshal(summaryCacheEntry{SummaryCacheKey: key, Summary: summary, StoredAt: time.Now()})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path(key), data)
+}
+
+// Prune removes summaries not used since olderThan as well as summaries made
+// with a previous version of the commit prompt, and returns how many were
+// removed.
+func (c *SummaryCache) Prune(olderThan time.Time) (int, error) {
+	removed, err := pruneDir(c.Dir, olderThan)
+	if err != nil {
+		return removed, err
+	}
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return removed, nil
+	}
+	if err != nil {
+		return removed, err
+	}
+	current := commitPromptHash()
+	for _, file := range entries {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(c.Dir, file.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return removed, err
+		}
+		var entry summaryCacheEntry
+		if json.Unmarshal(data, &entry) == nil && entry.PromptHash == current {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
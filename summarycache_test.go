@@ -0,0 +1,94 @@
+package ghsummary
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type countingSummarizer struct {
+	stubSummarizer
+	commits int
+}
+
+func (s *countingSummarizer) SummarizeCommit(ctx context.Context, content string) (string, error) {
+	s.commits++
+	return "Added a cache", nil
+}
+
+func (s *countingSummarizer) CommitModelName() string { return "test-model" }
+
+func TestGetCommitSummaryUsesSummaryCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"sha": "abc", "files": [{"filename": "cache.go", "patch": "+package cache"}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	cache, err := NewSummaryCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	summarizer := &countingSummarizer{}
+	opts := Options{GitHubAPIURL: server.URL, Summarizer: summarizer, SummaryCache: cache}
+	commit := RepoCommit{SHA: "abc", URL: server.URL + "/repos/octo/app/commits/abc"}
+	commit.Commit.Message = "Add cache"
+
+	for i := 0; i < 2; i++ {
+		summary, ok := GetCommitSummary(context.Background(), "octo/app", commit, opts)
+		if !ok || summary != "Added a cache" {
+			t.Fatalf("unexpected summary on call %d: %q, %v", i+1, summary, ok)
+		}
+	}
+	if summarizer.commits != 1 || requests != 1 {
+		t.Fatalf("expected one LLM call and one GitHub request, got %d and %d", summarizer.commits, requests)
+	}
+
+	// Another repository with the same SHA is a different commit.
+	if _, ok := GetCommitSummary(context.Background(), "octo/fork", commit, opts); !ok {
+		t.Fatal("expected a summary for the second repository")
+	}
+	if summarizer.commits != 2 {
+		t.Fatalf("expected the second repository to call the LLM, got %d calls", summarizer.commits)
+	}
+}
+
+func TestSummaryCachePrune(t *testing.T) {
+	cache, err := NewSummaryCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh := SummaryCacheKey{Repo: "octo/app", SHA: "1", Model: "m", PromptHash: commitPromptHash()}
+	old := SummaryCacheKey{Repo: "octo/app", SHA: "2", Model: "m", PromptHash: commitPromptHash()}
+	outdated := SummaryCacheKey{Repo: "octo/app", SHA: "3", Model: "m", PromptHash: "previous"}
+	for _, key := range []SummaryCacheKey{fresh, old, outdated} {
+		if err := cache.Put(key, "summary "+key.SHA); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	if err := os.Chtimes(cache.path(old), lastWeek, lastWeek); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := cache.Prune(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if removed != 2 {
+		t.Fatalf("expected 2 removed entries, got %d", removed)
+	}
+	if _, ok := cache.Get(fresh); !ok {
+		t.Error("expected the fresh entry to be kept")
+	}
+	files, _ := filepath.Glob(filepath.Join(cache.Dir, "*.json"))
+	if len(files) != 1 {
+		t.Errorf("expected one file left, got %v", files)
+	}
+}
@@ -1,6 +1,7 @@
 package ghsummary
 
 import (
+	"context"
 	"fmt"
 	"html"
 	"os"
@@ -8,8 +9,10 @@ import (
 	"time"
 )
 
-func GenerateSVGFile(text, outputPath string) error {
-	svgContent, err := GenerateSVG(text, outputPath)
+// GenerateSVGFile renders the text as SVG and writes it to outputPath. An
+// optional time window is stated in the footer.
+func GenerateSVGFile(ctx context.Context, text, outputPath string, window ...TimeWindow) error {
+	svgContent, err := GenerateSVG(ctx, text, outputPath, window...)
 	if err != nil {
 		return err
 	}
@@ -23,7 +26,13 @@ func GenerateSVGFile(text, outputPath string) error {
 	return err
 }
 
-func GenerateSVG(text, outputPath string) (string, error) {
+// GenerateSVG renders the text as SVG. An optional time window is stated in
+// the footer.
+func GenerateSVG(ctx context.Context, text, outputPath string, window ...TimeWindow) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	averageCharWidth := 10 // Width of a character in a monospaced font
 	maxWidth := 480        // Maximum width in pixels
 	maxCharsPerLine := maxWidth / averageCharWidth
@@ -56,7 +65,11 @@ func GenerateSVG(text, outputPath string) (string, error) {
 		y += 20 // Increment y position for the next line
 	}
 
-	// Add a generation timestamp at the bottom
+	// State the period covered and add a generation timestamp at the bottom
+	if len(window) > 0 && !window[0].IsZero() {
+		svgText += fmt.Sprintf(`<text x="%d" y="%d" text-anchor="end" font-family="Courier" font-size="10" fill="gray" fill-opacity="50%%">%s</text>`, maxWidth-10, y, html.EscapeString(window[0].String()))
+		y += 20
+	}
 	timestamp := svgTimestamp()
 	svgText += fmt.Sprintf(`<text x="%d" y="%d" text-anchor="end" font-family="Courier" font-size="10" fill="gray" fill-opacity="50%%">Generated on: %s</text>`, maxWidth-10, y, html.EscapeString(timestamp))
 	y += 20 // Increment y position for the timestamp
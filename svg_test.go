@@ -1,10 +1,12 @@
 package ghsummary
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestGenerateSVG(t *testing.T) {
@@ -12,7 +14,7 @@ func TestGenerateSVG(t *testing.T) {
 	testDir := t.TempDir()
 	outputPath := filepath.Join(testDir, "test_output.svg")
 
-	err := GenerateSVGFile("This is a test text that should be split into multiple lines if it exceeds the maximum width.", outputPath)
+	err := GenerateSVGFile(context.Background(), "This is a test text that should be split into multiple lines if it exceeds the maximum width.", outputPath)
 	if err != nil {
 		t.Fatalf("GenerateSVG failed: %v", err)
 	}
@@ -34,7 +36,7 @@ func TestGenerateSVG(t *testing.T) {
 }
 
 func TestGenerateSVGSupportsBasicMarkdownFormatting(t *testing.T) {
-	svg, err := GenerateSVG("Use **bold** and *italic* plus `code`", "")
+	svg, err := GenerateSVG(context.Background(), "Use **bold** and *italic* plus `code`", "")
 	if err != nil {
 		t.Fatalf("GenerateSVG failed: %v", err)
 	}
@@ -61,7 +63,7 @@ func TestGenerateSVGMatchesCommittedExample(t *testing.T) {
 		"`upload-image` GitHub action, implementing **Cloudinary** support, a delete image " +
 		"function, and fixing test issues."
 
-	if err := GenerateSVGFile(input, outputPath); err != nil {
+	if err := GenerateSVGFile(context.Background(), input, outputPath); err != nil {
 		t.Fatalf("GenerateSVGFile failed: %v", err)
 	}
 
@@ -79,3 +81,14 @@ func TestGenerateSVGMatchesCommittedExample(t *testing.T) {
 		t.Fatalf("generated SVG does not match committed example")
 	}
 }
+
+func TestGenerateSVGStatesPeriod(t *testing.T) {
+	window := TimeWindow{Since: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)}
+	svg, err := GenerateSVG(context.Background(), "Summary", "", window)
+	if err != nil {
+		t.Fatalf("GenerateSVG failed: %v", err)
+	}
+	if !strings.Contains(svg, "Activity since 2026-10-01") {
+		t.Errorf("expected the period in the footer: %s", svg)
+	}
+}
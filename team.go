@@ -0,0 +1,142 @@
+package ghsummary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// GetTeamMembers resolves a slug to logins. "org" lists the organization's
+// public members, and "org/team" lists the members of the team, which needs a
+// GITHUB_TOKEN with read:org access. Logins are returned in sorted order.
+func GetTeamMembers(ctx context.Context, slug string, opts Options) ([]string, error) {
+	org, team, isTeam := strings.Cut(slug, "/")
+	if org == "" || (isTeam && team == "") {
+		return nil, fmt.Errorf("invalid team slug %q, expected org or org/team", slug)
+	}
+	endpoint := fmt.Sprintf("%s/orgs/%s/members", opts.githubAPIURL(), org)
+	if isTeam {
+		endpoint = fmt.Sprintf("%s/orgs/%s/teams/%s/members", opts.githubAPIURL(), org, team)
+	}
+
+	const perPage, maxPages = 100, 10
+	var logins []string
+	for page := 1; page <= maxPages; page++ {
+		resp, err := makeGitHubRequest(ctx, fmt.Sprintf("%s?per_page=%d&page=%d", endpoint, perPage, page), opts)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to fetch members of %s: %s", slug, resp.Status)
+		}
+		var members []User
+		err = json.NewDecoder(resp.Body).Decode(&members)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding JSON response: %w", err)
+		}
+		for _, member := range members {
+			logins = append(logins, member.Login)
+		}
+		if len(members) < perPage {
+			break
+		}
+	}
+	sort.Strings(logins)
+	return logins, nil
+}
+
+// GetTeamActivity collects the activity of several users concurrently and
+// formats it for GenerateTeamSummary, grouped by member so the summary can
+// attribute the work. Members whose activity cannot be fetched are left out;
+// an error is returned only if no member could be fetched.
+func GetTeamActivity(ctx context.Context, team string, usernames []string, maxEvents int, mode string, opts Options) (string, error) {
+	usernames = uniqueLogins(usernames)
+	if len(usernames) == 0 {
+		return "", errors.New("no team members to summarize")
+	}
+	opts, err := prepareActivityOptions(ctx, mode, opts)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		log.Printf("GitHub API usage: %s", opts.RateLimiter.Stats())
+	}()
+
+	// Members are fetched in parallel; split the concurrency between them so
+	// the total number of requests in flight stays bounded.
+	workers := min(opts.concurrency(), len(usernames))
+	memberOpts := opts
+	memberOpts.Concurrency = max(1, opts.concurrency()/workers)
+
+	activities := make([][]Activity, len(usernames))
+	repositories := make([]map[string]struct{}, len(usernames))
+	errs := make([]error, len(usernames))
+	runConcurrently(ctx, len(usernames), workers, func(i int) {
+		activities[i], repositories[i], errs[i] = collectUserActivities(ctx, usernames[i], maxEvents, mode, memberOpts)
+	})
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	allRepositories := make(map[string]struct{})
+	fetched := 0
+	for i, username := range usernames {
+		if errs[i] != nil {
+			log.Printf("Error fetching activity for %s: %v", username, errs[i])
+			continue
+		}
+		fetched++
+		for repo := range repositories[i] {
+			allRepositories[repo] = struct{}{}
+		}
+	}
+	if fetched == 0 {
+		return "", fmt.Errorf("fetching team activity: %w", errors.Join(errs...))
+	}
+
+	if team == "" {
+		team = "the team"
+	}
+	recentActivities := fmt.Sprintf("Recent activities for %s (members: %s):\n", team, strings.Join(usernames, ", "))
+	if window := opts.timeWindow(); !window.IsZero() {
+		recentActivities += window.String() + ".\n"
+	}
+	descriptions, err := repositoryDescriptions(ctx, allRepositories, opts)
+	if err != nil {
+		return "", err
+	}
+	recentActivities += descriptions
+	for i, username := range usernames {
+		if errs[i] != nil || len(activities[i]) == 0 {
+			continue
+		}
+		recentActivities += fmt.Sprintf("Activities of %s:\n", username)
+		recentActivities += formatActivities(activities[i], false)
+	}
+	log.Printf("Team: %s", team)
+	log.Printf("Recent activities:\n %s", recentActivities)
+	return recentActivities, nil
+}
+
+// uniqueLogins drops repeated logins, which GitHub compares case-insensitively,
+// keeping the first occurrence.
+func uniqueLogins(logins []string) []string {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(logins))
+	for _, login := range logins {
+		key := strings.ToLower(login)
+		if login == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, login)
+	}
+	return unique
+}
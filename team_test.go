@@ -0,0 +1,78 @@
+package ghsummary
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetTeamMembers(t *testing.T) {
+	server := newFakeGitHubServer(t, map[string]string{
+		"/orgs/acme/teams/platform/members": `[{"login": "zoe"}, {"login": "adam"}]`,
+		"/orgs/acme/members":                `[{"login": "adam"}]`,
+	})
+	opts := Options{GitHubAPIURL: server.URL}
+
+	members, err := GetTeamMembers(context.Background(), "acme/platform", opts)
+	if err != nil {
+		t.Fatalf("GetTeamMembers failed: %v", err)
+	}
+	if !reflect.DeepEqual(members, []string{"adam", "zoe"}) {
+		t.Errorf("unexpected team members: %v", members)
+	}
+
+	members, err = GetTeamMembers(context.Background(), "acme", opts)
+	if err != nil || !reflect.DeepEqual(members, []string{"adam"}) {
+		t.Errorf("unexpected org members: %v, %v", members, err)
+	}
+
+	if _, err := GetTeamMembers(context.Background(), "acme/", opts); err == nil {
+		t.Error("expected an error for an invalid slug")
+	}
+}
+
+func TestGetTeamActivityAttributesMembers(t *testing.T) {
+	issue := func(id, repo, title string) string {
+		return `{"id": "` + id + `", "type": "IssuesEvent", "public": true, "repo": {"name": "` + repo + `"},
+			"payload": {"action": "opened", "issue": {"number": 1, "title": "` + title + `"}}}`
+	}
+	server := newFakeGitHubServer(t, map[string]string{
+		"/users/zoe/events":  `[` + issue("1", "acme/api", "Rate limiting") + `]`,
+		"/users/adam/events": `[` + issue("2", "acme/web", "Dark mode") + `]`,
+	})
+	opts := Options{GitHubAPIURL: server.URL}
+
+	// "ghost" has no events endpoint and is left out; "Zoe" repeats "zoe".
+	activity, err := GetTeamActivity(context.Background(), "acme/platform", []string{"zoe", "adam", "ghost", "Zoe"}, 10, "fast", opts)
+	if err != nil {
+		t.Fatalf("GetTeamActivity failed: %v", err)
+	}
+	for _, want := range []string{"Recent activities for acme/platform (members: zoe, adam, ghost):", "Activities of zoe:", "Activities of adam:"} {
+		if !strings.Contains(activity, want) {
+			t.Errorf("expected %q in activity:\n%s", want, activity)
+		}
+	}
+	zoe, adam := strings.Index(activity, "Rate limiting"), strings.Index(activity, "Dark mode")
+	if zoe < 0 || adam < 0 || zoe > adam {
+		t.Errorf("expected member sections in the given order:\n%s", activity)
+	}
+	if strings.Contains(activity, "Activities of ghost") || strings.Count(activity, "Activities of zoe") != 1 {
+		t.Errorf("unexpected member sections:\n%s", activity)
+	}
+
+	if _, err := GetTeamActivity(context.Background(), "", []string{"ghost"}, 10, "fast", opts); err == nil {
+		t.Error("expected an error when no member could be fetched")
+	}
+}
+
+func TestGenerateTeamSummaryFallsBackToSummarize(t *testing.T) {
+	stub := &stubSummarizer{}
+	summary, err := GenerateTeamSummary(context.Background(), stub, "activity", "")
+	if err != nil {
+		t.Fatalf("GenerateTeamSummary failed: %v", err)
+	}
+	if summary != "summary of activity" || stub.pronouns != "they/them" {
+		t.Errorf("unexpected fallback: %q with pronouns %q", summary, stub.pronouns)
+	}
+}
@@ -0,0 +1,528 @@
+package ghsummary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Default templates of the TemplateSummarizer, executed with *ActivityStats,
+// except for the commit template which gets *CommitStats. Whitespace in the
+// output is collapsed, so templates can be spread over several lines.
+const (
+	TemplateSummary = `{{if .Activities}}
+{{.Subject}} recently {{with .Highlights}}{{list .}}{{else}}stayed active{{end}}
+{{- with .RepositoryNames 3}}, mostly in {{list .}}{{end}}.
+{{with .OtherRepositories 3}}Activity in {{plural . "other repository"}} rounds out the picture.{{end}}
+{{else}}{{.Subject}} had no recent activity to summarize.{{end}}`
+	TemplateTeamSummary = `{{if .Activities}}
+{{.Subject}} recently {{with .Highlights}}{{list .}}{{else}}stayed active{{end}}
+{{- with .RepositoryNames 3}}, mostly in {{list .}}{{end}}.
+{{with .ContributorNames 3}}Most of the work came from {{list .}}.{{end}}
+{{else}}{{.Subject}} had no recent activity to summarize.{{end}}`
+	TemplateRepositorySummary = `{{if .Activities}}
+Recently in {{.Subject}}, contributors {{with .Highlights}}{{list .}}{{else}}stayed active{{end}}.
+{{with .ContributorNames 3}}Most of the work came from {{list .}}.{{end}}
+{{else}}Nothing happened recently in {{.Subject}}.{{end}}`
+	TemplateOrganizationSummary = `{{if .Activities}}
+The {{.Subject}} organization recently {{with .Highlights}}{{list .}}{{else}}stayed active{{end}}
+{{- with .RepositoryNames 3}}, most actively in {{list .}}{{end}}.
+{{with .ContributorNames 3}}Most of the work came from {{list .}}.{{end}}
+{{else}}Nothing happened recently in the {{.Subject}} organization.{{end}}`
+	TemplateCommitSummary = `{{.Message}}
+{{- with .Files}} ({{plural (len .) "file"}} changed: {{list (limit 3 .)}}{{if gt (len .) 3}} and more{{end}}){{end}}`
+)
+
+// TemplateSummarizer builds summaries from statistics of the collected
+// activity with text/template instead of an LLM: the most active
+// repositories, commit counts, merged pull requests, opened issues and so
+// on. It is deterministic and needs no network, so it serves both as the
+// "template" provider and as the fallback when an LLM fails.
+type TemplateSummarizer struct {
+	templates *template.Template
+}
+
+// CommitStats is the data of the commit template.
+type CommitStats struct {
+	// Message is the first line of the commit message.
+	Message string
+	// Files are the paths of the changed files with a patch.
+	Files []string
+}
+
+// ActivityStats is the data of the summary templates, counted from the
+// activity collected by GetUserActivity, GetTeamActivity,
+// GetRepositoryActivity or GetOrganizationActivity.
+type ActivityStats struct {
+	// Subject is the user, team, repository or organization summarized.
+	Subject string
+	// Pronouns are the user's pronouns; empty except for user summaries.
+	Pronouns string
+
+	Activities         int
+	Pushes             int
+	Commits            int
+	PullRequestsOpened int
+	PullRequestsMerged int
+	Reviews            int
+	IssuesOpened       int
+	IssuesClosed       int
+	Comments           int
+	Releases           int
+	NewRepositories    int
+
+	// Repositories and Contributors are sorted by activity, most active
+	// first, then by name.
+	Repositories []RepositoryStats
+	Contributors []ContributorStats
+}
+
+// RepositoryStats counts the activity in one repository.
+type RepositoryStats struct {
+	Name       string
+	Activities int
+	Commits    int
+}
+
+// ContributorStats counts the activity of one contributor.
+type ContributorStats struct {
+	Name       string
+	Activities int
+}
+
+// templateNames lists the templates a TemplateSummarizer executes, with
+// their defaults.
+var templateNames = map[string]string{
+	"summary":      TemplateSummary,
+	"team":         TemplateTeamSummary,
+	"repository":   TemplateRepositorySummary,
+	"organization": TemplateOrganizationSummary,
+	"commit":       TemplateCommitSummary,
+}
+
+var templateFuncs = template.FuncMap{
+	"list":   joinList,
+	"plural": plural,
+	"limit": func(n int, items []string) []string {
+		return items[:min(n, len(items))]
+	},
+}
+
+// NewTemplateSummarizer creates a template summarizer. Templates named
+// "<name>.tmpl" in dir, where name is summary, team, repository,
+// organization or commit, replace the defaults. An empty dir uses the
+// GHSUMMARY_TEMPLATES environment variable; when that is unset too, only the
+// defaults are used.
+func NewTemplateSummarizer(dir string) (*TemplateSummarizer, error) {
+	if dir == "" {
+		dir = os.Getenv("GHSUMMARY_TEMPLATES")
+	}
+	templates := template.New("").Funcs(templateFuncs)
+	names := make([]string, 0, len(templateNames))
+	for name := range templateNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		text := templateNames[name]
+		if dir != "" {
+			data, err := os.ReadFile(filepath.Join(dir, name+".tmpl"))
+			if err == nil {
+				text = string(data)
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("reading %s template: %w", name, err)
+			}
+		}
+		if _, err := templates.New(name).Parse(text); err != nil {
+			return nil, fmt.Errorf("parsing %s template: %w", name, err)
+		}
+	}
+	return &TemplateSummarizer{templates: templates}, nil
+}
+
+func (t *TemplateSummarizer) Summarize(ctx context.Context, activity string, pronouns string) (string, error) {
+	stats := CountActivity(activity, activitySubject(activity))
+	if stats.Subject == "" {
+		stats.Subject = "The user"
+	}
+	stats.Pronouns = pronouns
+	return t.execute("summary", stats)
+}
+
+func (t *TemplateSummarizer) SummarizeTeam(ctx context.Context, activity string, team string) (string, error) {
+	// GenerateTeamSummary names unnamed teams "the team".
+	if team == "the team" {
+		team = "The team"
+	}
+	return t.execute("team", CountActivity(activity, team))
+}
+
+func (t *TemplateSummarizer) SummarizeRepository(ctx context.Context, activity string, repo string) (string, error) {
+	return t.execute("repository", CountActivity(activity, repo))
+}
+
+func (t *TemplateSummarizer) SummarizeOrganization(ctx context.Context, activity string, org string) (string, error) {
+	return t.execute("organization", CountActivity(activity, org))
+}
+
+func (t *TemplateSummarizer) SummarizeCommit(ctx context.Context, content string) (string, error) {
+	var stats CommitStats
+	for _, line := range strings.Split(content, "\n") {
+		if message, ok := strings.CutPrefix(line, "Commit message: "); ok && stats.Message == "" {
+			stats.Message = strings.TrimSpace(message)
+		}
+		if file, ok := strings.CutPrefix(line, "File: "); ok {
+			stats.Files = append(stats.Files, file)
+		}
+	}
+	return t.execute("commit", &stats)
+}
+
+func (t *TemplateSummarizer) CommitModelName() string {
+	return "template"
+}
+
+func (t *TemplateSummarizer) execute(name string, data any) (string, error) {
+	var out strings.Builder
+	if err := t.templates.ExecuteTemplate(&out, name, data); err != nil {
+		return "", fmt.Errorf("executing %s template: %w", name, err)
+	}
+	// The summary goes into a single SVG text element.
+	summary := strings.Join(strings.Fields(out.String()), " ")
+	if summary == "" {
+		return "", fmt.Errorf("%s template produced an empty summary", name)
+	}
+	return summary, nil
+}
+
+// activityBlock is one activity of a prompt, as written by formatActivities.
+type activityBlock struct {
+	activityType string
+	actor        string
+	repository   string
+	content      []string
+}
+
+// CountActivity counts the activities in a prompt built by GetUserActivity
+// and the other activity collectors.
+func CountActivity(activity string, subject string) *ActivityStats {
+	stats := &ActivityStats{Subject: subject}
+	repositories := make(map[string]*RepositoryStats)
+	contributors := make(map[string]*ContributorStats)
+	var current *activityBlock
+	member := ""
+	flush := func() {
+		if current != nil {
+			stats.add(current, repositories, contributors)
+		}
+		current = nil
+	}
+	lines := strings.Split(activity, "\n")
+	for i, line := range lines {
+		// Activities are separated by a blank line, so a "Type: " line in
+		// release notes does not start one.
+		if activityType, ok := strings.CutPrefix(line, "Type: "); ok && (current == nil || lines[i-1] == "") {
+			flush()
+			current = &activityBlock{activityType: activityType, actor: member}
+			continue
+		}
+		// Team prompts list the activities of each member under a header,
+		// organization prompts those of each repository.
+		if name, ok := strings.CutPrefix(line, "Activities of "); ok && strings.HasSuffix(name, ":") {
+			flush()
+			member = strings.TrimSuffix(name, ":")
+			continue
+		}
+		if strings.HasPrefix(line, "Activities in ") && strings.HasSuffix(line, ":") {
+			flush()
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if current.content == nil {
+			if actor, ok := strings.CutPrefix(line, "By: "); ok {
+				current.actor = actor
+			} else if repo, ok := strings.CutPrefix(line, "Repository: "); ok {
+				current.repository = repo
+			} else if content, ok := strings.CutPrefix(line, "Content: "); ok {
+				current.content = []string{content}
+			}
+			continue
+		}
+		current.content = append(current.content, line)
+	}
+	flush()
+
+	for _, repo := range repositories {
+		stats.Repositories = append(stats.Repositories, *repo)
+	}
+	sort.Slice(stats.Repositories, func(i, j int) bool {
+		a, b := stats.Repositories[i], stats.Repositories[j]
+		if a.Activities != b.Activities {
+			return a.Activities > b.Activities
+		}
+		return a.Name < b.Name
+	})
+	for _, contributor := range contributors {
+		stats.Contributors = append(stats.Contributors, *contributor)
+	}
+	sort.Slice(stats.Contributors, func(i, j int) bool {
+		a, b := stats.Contributors[i], stats.Contributors[j]
+		if a.Activities != b.Activities {
+			return a.Activities > b.Activities
+		}
+		return a.Name < b.Name
+	})
+	return stats
+}
+
+// add counts an activity. Contribution counts from the GraphQL API count
+// as that many activities.
+func (s *ActivityStats) add(block *activityBlock, repositories map[string]*RepositoryStats, contributors map[string]*ContributorStats) {
+	first := ""
+	if len(block.content) > 0 {
+		first = block.content[0]
+	}
+	anonymized := first == privateActivityDescriptions[block.activityType]
+	activities, commits := 1, 0
+	switch block.activityType {
+	case "PushEvent":
+		s.Pushes++
+		if !anonymized {
+			commits = countPushedCommits(block.content)
+		}
+	case "PullRequestEvent":
+		switch {
+		case strings.HasPrefix(first, "Pull request merged"), strings.HasPrefix(first, "Merge request merged"):
+			s.PullRequestsMerged++
+		case strings.Contains(first, "ull request opened"), strings.HasPrefix(first, "Merge request opened"):
+			s.PullRequestsOpened++
+		}
+	case "PullRequestReviewEvent":
+		s.Reviews++
+	case "IssuesEvent":
+		switch {
+		case strings.HasPrefix(first, "Issue opened"):
+			s.IssuesOpened++
+		case strings.HasPrefix(first, "Issue closed"):
+			s.IssuesClosed++
+		}
+	case "IssueCommentEvent", "PullRequestReviewCommentEvent", "CommitCommentEvent":
+		s.Comments++
+	case "ReleaseEvent":
+		s.Releases++
+	case "CreateEvent":
+		if strings.HasPrefix(first, "Repository created") {
+			s.NewRepositories++
+		}
+	case "CommitContributions", "PullRequestContributions", "IssueContributions", "PullRequestReviewContributions":
+		count, _, _ := strings.Cut(first, " ")
+		activities, _ = strconv.Atoi(count)
+		switch block.activityType {
+		case "CommitContributions":
+			commits = activities
+		case "PullRequestContributions":
+			s.PullRequestsOpened += activities
+		case "IssueContributions":
+			s.IssuesOpened += activities
+		case "PullRequestReviewContributions":
+			s.Reviews += activities
+		}
+	case "ContributionTotals", "ContributionCalendar":
+		return
+	}
+	s.Activities += activities
+	s.Commits += commits
+
+	if block.repository != "" {
+		repo, ok := repositories[block.repository]
+		if !ok {
+			repo = &RepositoryStats{Name: block.repository}
+			repositories[block.repository] = repo
+		}
+		repo.Activities += activities
+		repo.Commits += commits
+	}
+	if block.actor != "" {
+		contributor, ok := contributors[block.actor]
+		if !ok {
+			contributor = &ContributorStats{Name: block.actor}
+			contributors[block.actor] = contributor
+		}
+		contributor.Activities += activities
+	}
+}
+
+// countPushedCommits counts the user's commits in the content of a push, as
+// written by pushCommits.messages: one line per commit, with indented
+// continuation lines, merges and commits by others listed separately.
+func countPushedCommits(content []string) int {
+	commits := 0
+	for _, line := range content {
+		switch {
+		case strings.TrimSpace(line) == "", strings.HasPrefix(line, " "),
+			strings.HasPrefix(line, "Merge commit: "), strings.HasPrefix(line, "The push also contained "):
+		case strings.HasPrefix(line, "The push contained "):
+			var more int
+			if _, err := fmt.Sscanf(line, "The push contained %d more", &more); err == nil {
+				commits += more
+			}
+		default:
+			commits++
+		}
+	}
+	return commits
+}
+
+// Highlights describes the counted activity as phrases such as
+// "pushed 3 commits", in a fixed order and leaving out zero counts.
+func (s *ActivityStats) Highlights() []string {
+	var highlights []string
+	for _, highlight := range []struct {
+		count  int
+		phrase string
+		noun   string
+	}{
+		{s.Commits, "pushed", "commit"},
+		{s.PullRequestsMerged, "merged", "pull request"},
+		{s.PullRequestsOpened, "opened", "pull request"},
+		{s.Reviews, "reviewed", "pull request"},
+		{s.IssuesOpened, "opened", "issue"},
+		{s.IssuesClosed, "closed", "issue"},
+		{s.Comments, "left", "comment"},
+		{s.Releases, "published", "release"},
+		{s.NewRepositories, "created", "repository"},
+	} {
+		if highlight.count > 0 {
+			highlights = append(highlights, highlight.phrase+" "+plural(highlight.count, highlight.noun))
+		}
+	}
+	return highlights
+}
+
+// RepositoryNames returns the names of the n most active repositories.
+func (s *ActivityStats) RepositoryNames(n int) []string {
+	var names []string
+	for _, repo := range s.Repositories[:min(n, len(s.Repositories))] {
+		names = append(names, repo.Name)
+	}
+	return names
+}
+
+// OtherRepositories returns the number of repositories beyond the n most
+// active ones.
+func (s *ActivityStats) OtherRepositories(n int) int {
+	return max(len(s.Repositories)-n, 0)
+}
+
+// ContributorNames returns the names of the n most active contributors.
+func (s *ActivityStats) ContributorNames(n int) []string {
+	var names []string
+	for _, contributor := range s.Contributors[:min(n, len(s.Contributors))] {
+		names = append(names, contributor.Name)
+	}
+	return names
+}
+
+// joinList joins items as in "a, b and c".
+func joinList(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// plural formats a count with its noun, e.g. "1 commit" or "2 repositories".
+func plural(count int, noun string) string {
+	if count != 1 {
+		if strings.HasSuffix(noun, "y") {
+			noun = strings.TrimSuffix(noun, "y") + "ies"
+		} else {
+			noun += "s"
+		}
+	}
+	return fmt.Sprintf("%d %s", count, noun)
+}
+
+// FallbackSummarizer summarizes with Primary and, when it fails, with
+// Fallback, so a summary is still produced while the LLM is unavailable.
+// Commit summaries never fall back: strict mode already uses the commit
+// message instead, and a fallback summary would be cached as Primary's.
+type FallbackSummarizer struct {
+	Primary  Summarizer
+	Fallback Summarizer
+}
+
+// WithTemplateFallback wraps summarizer so that a TemplateSummarizer with
+// the templates in templateDir answers when it fails. Template summarizers
+// are returned as they are.
+func WithTemplateFallback(summarizer Summarizer, templateDir string) (Summarizer, error) {
+	switch summarizer.(type) {
+	case *TemplateSummarizer, *FallbackSummarizer:
+		return summarizer, nil
+	}
+	templates, err := NewTemplateSummarizer(templateDir)
+	if err != nil {
+		return nil, err
+	}
+	return &FallbackSummarizer{Primary: summarizer, Fallback: templates}, nil
+}
+
+// fallback returns the primary result, or the fallback's when the primary
+// failed for another reason than ctx ending.
+func (f *FallbackSummarizer) fallback(ctx context.Context, summary string, err error, fallback func() (string, error)) (string, error) {
+	if err == nil || ctx.Err() != nil {
+		return summary, err
+	}
+	log.Printf("Summarizer failed, falling back: %v", err)
+	return fallback()
+}
+
+func (f *FallbackSummarizer) Summarize(ctx context.Context, activity string, pronouns string) (string, error) {
+	summary, err := f.Primary.Summarize(ctx, activity, pronouns)
+	return f.fallback(ctx, summary, err, func() (string, error) {
+		return f.Fallback.Summarize(ctx, activity, pronouns)
+	})
+}
+
+func (f *FallbackSummarizer) SummarizeTeam(ctx context.Context, activity string, team string) (string, error) {
+	summary, err := GenerateTeamSummary(ctx, f.Primary, activity, team)
+	return f.fallback(ctx, summary, err, func() (string, error) {
+		return GenerateTeamSummary(ctx, f.Fallback, activity, team)
+	})
+}
+
+func (f *FallbackSummarizer) SummarizeRepository(ctx context.Context, activity string, repo string) (string, error) {
+	summary, err := GenerateRepositorySummary(ctx, f.Primary, activity, repo)
+	return f.fallback(ctx, summary, err, func() (string, error) {
+		return GenerateRepositorySummary(ctx, f.Fallback, activity, repo)
+	})
+}
+
+func (f *FallbackSummarizer) SummarizeOrganization(ctx context.Context, activity string, org string) (string, error) {
+	summary, err := GenerateOrganizationSummary(ctx, f.Primary, activity, org)
+	return f.fallback(ctx, summary, err, func() (string, error) {
+		return GenerateOrganizationSummary(ctx, f.Fallback, activity, org)
+	})
+}
+
+func (f *FallbackSummarizer) SummarizeCommit(ctx context.Context, content string) (string, error) {
+	return f.Primary.SummarizeCommit(ctx, content)
+}
+
+// CommitModelName names Primary's model, as only it summarizes commits.
+func (f *FallbackSummarizer) CommitModelName() string {
+	if namer, ok := f.Primary.(CommitModelNamer); ok {
+		return namer.CommitModelName()
+	}
+	return fmt.Sprintf("%T", f.Primary)
+}
@@ -0,0 +1,164 @@
+package ghsummary
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failingSummarizer fails every request like an unreachable LLM.
+type failingSummarizer struct{}
+
+func (failingSummarizer) Summarize(ctx context.Context, activity string, pronouns string) (string, error) {
+	return "", ErrProviderUnavailable
+}
+
+func (failingSummarizer) SummarizeCommit(ctx context.Context, content string) (string, error) {
+	return "", ErrProviderUnavailable
+}
+
+func TestCountActivity(t *testing.T) {
+	activities := []Activity{
+		{Type: "PushEvent", Repository: "octo/app", Content: indentMessage("Add cache\n\nStores summaries on disk.") + "\nFix typo\nMerge commit: Merge main\nThe push contained 2 more commits.\n"},
+		{Type: "PullRequestEvent", Repository: "octo/app", Content: "Pull request merged: #12 Cache commit summaries\n"},
+		{Type: "PullRequestEvent", Repository: "acme/lib", Content: "Draft pull request opened: #3 Faster parser\n"},
+		{Type: "IssuesEvent", Repository: "acme/lib", Content: "Issue opened: #7 Panic on empty input\n"},
+		{Type: "IssueCommentEvent", Repository: "acme/lib", Content: "Commented on issue #7: Panic on empty input (open)\nComment: Fixed in #3.\n"},
+		{Type: "PushEvent", Repository: PrivateRepositoryName, Content: "Pushed commits"},
+		{Type: "ReleaseEvent", Repository: "octo/app", Content: "Release published: v1.0 (tag v1.0)\nRelease notes:\nType: not an activity\n"},
+	}
+	activity := "Recent activities for user octo:\n" + formatActivities(activities, false)
+
+	stats := CountActivity(activity, "octo")
+	if stats.Activities != 7 || stats.Pushes != 2 || stats.Commits != 4 || stats.PullRequestsMerged != 1 || stats.PullRequestsOpened != 1 ||
+		stats.IssuesOpened != 1 || stats.Comments != 1 || stats.Releases != 1 {
+		t.Errorf("unexpected counts: %+v", stats)
+	}
+	// Ties are broken by name.
+	want := []RepositoryStats{{Name: "acme/lib", Activities: 3}, {Name: "octo/app", Activities: 3, Commits: 4}, {Name: PrivateRepositoryName, Activities: 1}}
+	if len(stats.Repositories) != len(want) {
+		t.Fatalf("expected repositories %+v, got %+v", want, stats.Repositories)
+	}
+	for i := range want {
+		if stats.Repositories[i] != want[i] {
+			t.Errorf("repository %d: expected %+v, got %+v", i, want[i], stats.Repositories[i])
+		}
+	}
+
+	if commits := countPushedCommits([]string{"Fix typo", "The push contained 1 more commit."}); commits != 2 {
+		t.Errorf("expected 2 commits in a push with 1 more commit, got %d", commits)
+	}
+
+	summarizer, err := NewTemplateSummarizer("")
+	if err != nil {
+		t.Fatalf("NewTemplateSummarizer failed: %v", err)
+	}
+	summary, err := summarizer.Summarize(context.Background(), activity, "they/them")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	wantSummary := "octo recently pushed 4 commits, merged 1 pull request, opened 1 pull request, opened 1 issue, left 1 comment and published 1 release, mostly in acme/lib, octo/app and a private repository."
+	if summary != wantSummary {
+		t.Errorf("expected summary %q, got %q", wantSummary, summary)
+	}
+}
+
+func TestTemplateSummarizerTeamAndOrganization(t *testing.T) {
+	summarizer, err := NewTemplateSummarizer("")
+	if err != nil {
+		t.Fatalf("NewTemplateSummarizer failed: %v", err)
+	}
+	team := "Recent activities for the team (members: octo, hubot):\nActivities of octo:\n" +
+		formatActivities([]Activity{{Type: "PullRequestReviewEvent", Repository: "octo/app", Content: "Approved pull request: #12 Cache\n"}}, false) +
+		"Activities of hubot:\n" +
+		formatActivities([]Activity{
+			{Type: "PushEvent", Repository: "octo/app", Content: "Bump version\n"},
+			{Type: "CreateEvent", Repository: "hubot/new", Content: "Repository created\n"},
+		}, false)
+	summary, err := GenerateTeamSummary(context.Background(), summarizer, team, "")
+	want := "The team recently pushed 1 commit, reviewed 1 pull request and created 1 repository, mostly in octo/app and hubot/new. Most of the work came from hubot and octo."
+	if err != nil || summary != want {
+		t.Errorf("expected team summary %q, got %q, %v", want, summary, err)
+	}
+
+	org := "Recent activities in organization acme:\nMost active repositories: acme/lib (1 events)\nActivities in acme/lib:\n" +
+		formatActivities([]Activity{{Type: "IssuesEvent", Actor: "octo", Repository: "acme/lib", Content: "Issue closed: #7 Panic\n"}}, true)
+	summary, err = GenerateOrganizationSummary(context.Background(), summarizer, org, "acme")
+	want = "The acme organization recently closed 1 issue, most actively in acme/lib. Most of the work came from octo."
+	if err != nil || summary != want {
+		t.Errorf("expected organization summary %q, got %q, %v", want, summary, err)
+	}
+
+	summary, err = GenerateCommitSummary(context.Background(), summarizer, "Commit message: Add cache\n\nLonger body\nFile: cache.go\nPatch:\n+package cache\nFile: cache_test.go\nPatch:\n")
+	want = "Add cache (2 files changed: cache.go and cache_test.go)"
+	if err != nil || summary != want {
+		t.Errorf("expected commit summary %q, got %q, %v", want, summary, err)
+	}
+}
+
+func TestTemplateSummarizerOverrides(t *testing.T) {
+	dir := t.TempDir()
+	template := `{{.Subject}} ({{.Pronouns}}):
+		{{range .Repositories}}{{.Name}}={{.Commits}} {{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "summary.tmpl"), []byte(template), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GHSUMMARY_TEMPLATES", dir)
+	summarizer, err := NewSummarizer("template")
+	if err != nil {
+		t.Fatalf("NewSummarizer failed: %v", err)
+	}
+	activity := "Recent activities for user octo:\n" + formatActivities([]Activity{{Type: "PushEvent", Repository: "octo/app", Content: "One\nTwo\n"}}, false)
+	summary, err := GenerateSummary(context.Background(), summarizer, activity, "she/her")
+	if err != nil || summary != "octo (she/her): octo/app=2" {
+		t.Errorf("unexpected summary %q, %v", summary, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "team.tmpl"), []byte("{{.Missing"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTemplateSummarizer(dir); err == nil || !strings.Contains(err.Error(), "parsing team template") {
+		t.Errorf("expected a parse error for the team template, got %v", err)
+	}
+}
+
+func TestFallbackSummarizer(t *testing.T) {
+	summarizer, err := WithTemplateFallback(failingSummarizer{}, "")
+	if err != nil {
+		t.Fatalf("WithTemplateFallback failed: %v", err)
+	}
+	activity := "Recent activities for user octo:\n" + formatActivities([]Activity{{Type: "IssuesEvent", Repository: "octo/app", Content: "Issue opened: #1 Crash\n"}}, false)
+	summary, err := GenerateSummary(context.Background(), summarizer, activity)
+	if err != nil || summary != "octo recently opened 1 issue, mostly in octo/app." {
+		t.Errorf("expected the template summary, got %q, %v", summary, err)
+	}
+	if _, err := GenerateCommitSummary(context.Background(), summarizer, "Commit message: Fix\n"); !errors.Is(err, ErrProviderUnavailable) {
+		t.Errorf("expected commit summaries not to fall back, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := GenerateSummary(ctx, summarizer, activity); !errors.Is(err, ErrProviderUnavailable) {
+		t.Errorf("expected no fallback once the context is done, got %v", err)
+	}
+}
+
+func TestGenerateSummarySVGFallsBackToTemplates(t *testing.T) {
+	opts := replayOctoFixtures()
+	opts.Summarizer = failingSummarizer{}
+	svg, err := GenerateSummarySVG(context.Background(), "octo", 100, "fast", opts)
+	if err != nil {
+		t.Fatalf("GenerateSummarySVG failed: %v", err)
+	}
+	if !strings.Contains(svg, "octo recently pushed 2 commits") {
+		t.Errorf("expected the template summary in:\n%s", svg)
+	}
+
+	opts.DisableFallback = true
+	if _, err := GenerateSummarySVG(context.Background(), "octo", 100, "fast", opts); !errors.Is(err, ErrProviderUnavailable) {
+		t.Errorf("expected the LLM error without the fallback, got %v", err)
+	}
+}
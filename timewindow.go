@@ -0,0 +1,98 @@
+package ghsummary
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// TimeWindow limits the summary to events created at or after Since and
+// before Until. A zero bound leaves that side open.
+type TimeWindow struct {
+	Since time.Time
+	Until time.Time
+}
+
+// IsZero reports whether the window has no bounds.
+func (w TimeWindow) IsZero() bool {
+	return w.Since.IsZero() && w.Until.IsZero()
+}
+
+// Contains reports whether t falls within the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	if !w.Since.IsZero() && t.Before(w.Since) {
+		return false
+	}
+	if !w.Until.IsZero() && !t.Before(w.Until) {
+		return false
+	}
+	return true
+}
+
+// String describes the period covered, e.g. "Activity from 2026-10-10 to
+// 2026-10-16". Until is exclusive, so the last day shown is the one just
+// before it.
+func (w TimeWindow) String() string {
+	const layout = "2006-01-02"
+	since := w.Since.Format(layout)
+	until := w.Until.Add(-time.Nanosecond).Format(layout)
+	switch {
+	case w.IsZero():
+		return ""
+	case w.Until.IsZero():
+		return "Activity since " + since
+	case w.Since.IsZero():
+		return "Activity until " + until
+	}
+	return fmt.Sprintf("Activity from %s to %s", since, until)
+}
+
+var relativeDurationPattern = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// ParseTimeWindow parses the --since and --until values. Each is either a
+// date (2006-01-02), an RFC 3339 timestamp or a duration before now such as
+// "7d", "2w" or "36h". A date used for until includes that whole day.
+func ParseTimeWindow(since, until string, now time.Time) (TimeWindow, error) {
+	var window TimeWindow
+	var err error
+	if since != "" {
+		if window.Since, err = parseTimeBound(since, now, false); err != nil {
+			return TimeWindow{}, fmt.Errorf("invalid since %q: %w", since, err)
+		}
+	}
+	if until != "" {
+		if window.Until, err = parseTimeBound(until, now, true); err != nil {
+			return TimeWindow{}, fmt.Errorf("invalid until %q: %w", until, err)
+		}
+	}
+	if !window.Since.IsZero() && !window.Until.IsZero() && !window.Since.Before(window.Until) {
+		return TimeWindow{}, fmt.Errorf("since %q is not before until %q", since, until)
+	}
+	return window, nil
+}
+
+func parseTimeBound(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		if endOfDay {
+			date = date.AddDate(0, 0, 1)
+		}
+		return date, nil
+	}
+	if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+		return timestamp, nil
+	}
+	if match := relativeDurationPattern.FindStringSubmatch(value); match != nil {
+		count, _ := strconv.Atoi(match[1])
+		days := count
+		if match[2] == "w" {
+			days *= 7
+		}
+		return now.AddDate(0, 0, -days), nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return time.Time{}, fmt.Errorf("expected a date, an RFC 3339 timestamp or a duration like 7d")
+	}
+	return now.Add(-duration), nil
+}
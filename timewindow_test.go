@@ -0,0 +1,62 @@
+package ghsummary
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeWindow(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		since, until string
+		want         TimeWindow
+		wantErr      bool
+	}{
+		{name: "empty"},
+		{name: "days", since: "7d", want: TimeWindow{Since: now.AddDate(0, 0, -7)}},
+		{name: "weeks", since: "2w", want: TimeWindow{Since: now.AddDate(0, 0, -14)}},
+		{name: "hours", since: "36h", want: TimeWindow{Since: now.Add(-36 * time.Hour)}},
+		{
+			name: "dates", since: "2026-10-01", until: "2026-10-14",
+			want: TimeWindow{Since: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), Until: time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "timestamp", until: "2026-10-16T08:30:00Z",
+			want: TimeWindow{Until: time.Date(2026, 10, 16, 8, 30, 0, 0, time.UTC)},
+		},
+		{name: "invalid", since: "last week", wantErr: true},
+		{name: "negative", since: "-5h", wantErr: true},
+		{name: "since after until", since: "1d", until: "7d", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimeWindow(tt.since, tt.until, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Since.Equal(tt.want.Since) || !got.Until.Equal(tt.want.Until) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeWindowString(t *testing.T) {
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		window TimeWindow
+		want   string
+	}{
+		{TimeWindow{}, ""},
+		{TimeWindow{Since: since}, "Activity since 2026-10-01"},
+		{TimeWindow{Until: until}, "Activity until 2026-10-14"},
+		{TimeWindow{Since: since, Until: until}, "Activity from 2026-10-01 to 2026-10-14"},
+	}
+	for _, tt := range tests {
+		if got := tt.window.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"log"
 	"regexp"
+	"strings"
 )
 
 func SanitizeUsername(username string) bool {
@@ -34,6 +35,36 @@ func SanitizeInputs(username string, outputFile string) bool {
 		return false
 	}
 
+	return SanitizeOutputFile(outputFile)
+}
+
+// SanitizeTeamSlug validates an "org" or "org/team" slug.
+func SanitizeTeamSlug(slug string) bool {
+	org, team, isTeam := strings.Cut(slug, "/")
+	if !SanitizeUsername(org) {
+		return false
+	}
+	if isTeam && !regexp.MustCompile(`^[a-zA-Z0-9_-]{1,100}$`).MatchString(team) {
+		log.Printf("Invalid team name: %s", team)
+		return false
+	}
+	return true
+}
+
+// SanitizeRepo validates an "owner/name" repository reference.
+func SanitizeRepo(repo string) bool {
+	owner, name, found := strings.Cut(repo, "/")
+	if !found || !SanitizeUsername(owner) {
+		return false
+	}
+	if name == "." || name == ".." || !regexp.MustCompile(`^[a-zA-Z0-9._-]{1,100}$`).MatchString(name) {
+		log.Printf("Invalid repository name: %s", name)
+		return false
+	}
+	return true
+}
+
+func SanitizeOutputFile(outputFile string) bool {
 	if outputFile == "" {
 		log.Printf("Output file is empty")
 		return false
@@ -33,3 +33,38 @@ func TestSanitizeInputs(t *testing.T) {
 		})
 	}
 }
+
+func TestSanitizeTeamSlug(t *testing.T) {
+	tests := map[string]bool{
+		"acme":             true,
+		"acme/platform":    true,
+		"acme/web_team-2":  true,
+		"acme/":            false,
+		"/platform":        false,
+		"acme/plat/form":   false,
+		"acme/../platform": false,
+		"":                 false,
+	}
+	for slug, expected := range tests {
+		if result := SanitizeTeamSlug(slug); result != expected {
+			t.Errorf("SanitizeTeamSlug(%q) = %v; want %v", slug, result, expected)
+		}
+	}
+}
+
+func TestSanitizeRepo(t *testing.T) {
+	tests := map[string]bool{
+		"McCzarny/ghsummary": true,
+		"octo/my.repo_v2-x":  true,
+		"octo":               false,
+		"octo/":              false,
+		"octo/..":            false,
+		"octo/a/b":           false,
+		"bad user/repo":      false,
+	}
+	for repo, expected := range tests {
+		if result := SanitizeRepo(repo); result != expected {
+			t.Errorf("SanitizeRepo(%q) = %v; want %v", repo, result, expected)
+		}
+	}
+}